@@ -0,0 +1,233 @@
+package cmd
+
+import (
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	initCmd = &cobra.Command{
+		Use:   "init",
+		Short: "Create the manual approval request",
+		Long: `Create the manual approval request and wait for one of the approvers to respond.
+
+Inputs:
+  --approvers                   Comma separated list of user IDs or email addresses (env APPROVERS)
+  --instructions                Instructions for approvers in markdown format (env INSTRUCTIONS)
+  --disallow-launched-by-user   true to prevent the user who started the workflow from approving (env DISALLOW_LAUNCHED_BY_USER)
+  --notify-all-eligible-users   true to notify all users who are eligible to approve (env NOTIFY_ALL_ELIGIBLE_USERS)
+  --disallowed-approvers        Users who may not approve and "authors-of: <commit range>" entries (env DISALLOWED_APPROVERS)
+  --distinct-from               decisionRecord outputs of earlier approval jobs whose approvers may not approve (env DISTINCT_FROM)
+  --inputs                      approvalInputs definition in YAML format (env INPUTS)
+  --delegations                 Approver substitutes, one "<approver> -> <substitute> [from <yyyy-mm-dd>] [to <yyyy-mm-dd>]" per line (env DELEGATIONS)
+  --delegations-file            File with approver substitutes in the same format (env DELEGATIONS_FILE)
+  --approval-key                What is approved, such as an image digest, approvals are saved under it (env APPROVAL_KEY)
+  --reuse-window                How long an approval of the same approval key is reused, such as 24h (env REUSE_WINDOW)
+  --approval-store              File or http(s) URL approvals are saved to and read from (env APPROVAL_STORE,
+                                the bearer token of an HTTP store is read from APPROVAL_STORE_TOKEN)
+
+The delegations applied are written to the delegations output in --outputs-dir
+and the job status to --status-file. When an approval is reused, the job is approved
+without a new request and the decisionRecord and reusedApprovalId outputs point to it.`,
+		RunE: runHandler("init"),
+	}
+
+	callbackCmd = &cobra.Command{
+		Use:   "callback",
+		Short: "Process the approver response",
+		Long: `Process the approver response and update the manual approval status.
+
+Inputs:
+  --payload                     Approver response in JSON format (env PAYLOAD)
+  --disallowed-approvers        Users who may not approve, an approval by one of them fails the job (env DISALLOWED_APPROVERS)
+  --distinct-from               decisionRecord outputs of earlier approval jobs, an approval by their approvers fails the job (env DISTINCT_FROM)
+  --require-comment-on-reject   true to fail rejections without a comment (env REQUIRE_COMMENT_ON_REJECT)
+  --min-comment-length          Minimum number of characters of a rejection comment (env MIN_COMMENT_LENGTH)
+  --rejection-reasons           Comma separated reason codes one of which every rejection has to give (env REJECTION_REASONS)
+  --approval-key                What is approved, approvals are saved under it to --approval-store (env APPROVAL_KEY)
+  --approval-store              File or http(s) URL approvals are saved to (env APPROVAL_STORE)
+
+The approvalInputValues, comments, reasonCode and decisionRecord outputs are written to
+--outputs-dir and the job status to --status-file.`,
+		RunE: runHandler("callback"),
+	}
+
+	cancelCmd = &cobra.Command{
+		Use:   "cancel",
+		Short: "Cancel the manual approval request",
+		Long: `Cancel the manual approval request when the workflow is aborted or timed out.
+
+Inputs:
+  --reason   CANCELLED when the workflow was aborted, anything else marks the request as timed out (env CANCELLATION_REASON)`,
+		RunE: runHandler("cancel"),
+	}
+
+	waitCmd = &cobra.Command{
+		Use:   "wait",
+		Short: "Request approval and block until it is approved, rejected or timed out",
+		Long: `Create the manual approval request like init does, then poll its status until it
+is approved, rejected or --timeout passes. The outputs and status are written
+like the callback handler writes them, so the command can be used as an
+ordinary pipeline step outside of the custom job.
+
+Inputs are the same as for init.
+
+With --backend tty the approval is not requested from the platform. The
+instructions and the approvalInputs form are shown on the terminal instead and
+the answers are collected there, which is useful for local dry runs and
+air-gapped environments.
+
+With --backend file the request is written to <id>.request.json in
+--backend-dir and the command waits for <id>.response.json to be dropped
+next to it, for example by the approve and reject subcommands or by a commit
+to a shared repository.
+
+Exit codes:
+  0   approved
+  1   failure
+  2   rejected
+  3   timed out
+  4   cancelled, the request is aborted when the command is interrupted
+  5   invalid or missing configuration
+  6   invalid approval inputs or response
+  7   platform API rejected the request (HTTP 4xx)
+  8   platform API failed (HTTP 5xx)`,
+		RunE:         runHandler("wait"),
+		SilenceUsage: true,
+	}
+
+	validateCmd = &cobra.Command{
+		Use:   "validate",
+		Short: "Check the manual approval job configuration without calling the API",
+		Long: `Check the manual approval job configuration without calling the API.
+
+The configuration is read from --file, which is either a workflow file, in
+which case every job delegating to the manual approval custom job is checked,
+or the 'with' mapping of a single job. Without --file the init flags and
+environment variables are checked.
+
+Approver formats, markdown instructions, boolean inputs and the approvalInputs
+schema are checked. Every problem is reported with its line number and the
+command exits with a non-zero status when any problem is found.`,
+		RunE:         runHandler("validate"),
+		SilenceUsage: true,
+	}
+)
+
+var (
+	statusCmd = &cobra.Command{
+		Use:   "status",
+		Short: "Show the state of an approval request",
+		Long: `Show the state, eligible approvers, time remaining and instructions of an approval request.
+
+The platform API is called with --url and the token from --token-file or API_TOKEN.`,
+		RunE: runHandler("status"),
+	}
+
+	listCmd = &cobra.Command{
+		Use:   "list",
+		Short: "List approval requests",
+		Long: `List the approval requests visible to the user of the API token.
+
+Use --pending to only list requests that are waiting for a response.`,
+		RunE: runHandler("list"),
+	}
+
+	approveCmd = &cobra.Command{
+		Use:   "approve",
+		Short: "Approve an approval request",
+		Long: `Approve an approval request as the user owning the API token.
+
+Input values are given as --input name=value and are checked against the
+approvalInputs of the request. Inputs that are not given use their default
+value, required inputs without a default value must be given.`,
+		RunE:         runHandler("approve"),
+		SilenceUsage: true,
+	}
+
+	rejectCmd = &cobra.Command{
+		Use:   "reject",
+		Short: "Reject an approval request",
+		Long: `Reject an approval request as the user owning the API token.
+
+Input values are given as --input name=value and are checked against the
+approvalInputs of the request, required inputs do not have to be given.
+When --rejection-reasons is set, --reason-code
+must be one of them, and --require-comment-on-reject and --min-comment-length
+apply to --comments.`,
+		RunE:         runHandler("reject"),
+		SilenceUsage: true,
+	}
+)
+
+// runHandler runs the manual approval handler with the given name
+func runHandler(handler string) func(command *cobra.Command, args []string) error {
+	return func(command *cobra.Command, args []string) error {
+		cfg.Handler = handler
+		return run(command, args)
+	}
+}
+
+func init() {
+	initCmd.Flags().StringVar(&cfg.Approvers, "approvers", "", "Comma separated list of approvers (env APPROVERS)")
+	initCmd.Flags().StringVar(&cfg.Instructions, "instructions", "", "Instructions for approvers in markdown format (env INSTRUCTIONS)")
+	initCmd.Flags().StringVar(&cfg.DisallowLaunchedByUser, "disallow-launched-by-user", "", "Prevent the user who started the workflow from approving: true or false (env DISALLOW_LAUNCHED_BY_USER, default false)")
+	initCmd.Flags().StringVar(&cfg.NotifyAllEligibleUsers, "notify-all-eligible-users", "", "Notify all users who are eligible to approve: true or false (env NOTIFY_ALL_ELIGIBLE_USERS, default false)")
+	initCmd.Flags().StringVar(&cfg.Inputs, "inputs", "", "approvalInputs definition in YAML format (env INPUTS)")
+	initCmd.Flags().StringVar(&cfg.DisallowedApprovers, "disallowed-approvers", "", "Comma separated users who may not approve, \"authors-of: <commit range>\" stands for the commit authors in the git checkout (env DISALLOWED_APPROVERS)")
+	initCmd.Flags().StringVar(&cfg.DistinctFrom, "distinct-from", "", "decisionRecord outputs of earlier approval jobs, as a JSON list or one per line, whose approvers may not approve (env DISTINCT_FROM)")
+	initCmd.Flags().StringVar(&cfg.Delegations, "delegations", "", "Approver substitutes, one \"<approver> -> <substitute> [from <yyyy-mm-dd>] [to <yyyy-mm-dd>]\" per line (env DELEGATIONS)")
+	initCmd.Flags().StringVar(&cfg.DelegationsFile, "delegations-file", "", "File with approver substitutes in the same format as --delegations (env DELEGATIONS_FILE)")
+	initCmd.Flags().StringVar(&cfg.ReuseWindow, "reuse-window", "", "How long an approval of the same --approval-key is reused instead of requesting a new one, such as 24h (env REUSE_WINDOW)")
+
+	waitCmd.Flags().AddFlagSet(initCmd.Flags())
+	waitCmd.Flags().DurationVar(&cfg.PollInterval, "poll-interval", 5*time.Second, "Initial interval between status checks, doubled after every check up to one minute")
+	waitCmd.Flags().DurationVar(&cfg.Timeout, "timeout", 4320*time.Minute, "How long to wait for a response before the request times out")
+
+	callbackCmd.Flags().StringVar(&cfg.Payload, "payload", "", "Approver response in JSON format (env PAYLOAD)")
+	callbackCmd.Flags().StringVar(&cfg.Inputs, "inputs", "", "approvalInputs definition in YAML format the response values are checked against (env INPUTS)")
+	callbackCmd.Flags().StringVar(&cfg.DisallowedApprovers, "disallowed-approvers", "", "Comma separated users who may not approve, \"authors-of: <commit range>\" stands for the commit authors in the git checkout (env DISALLOWED_APPROVERS)")
+	callbackCmd.Flags().StringVar(&cfg.DisallowedAuthors, "disallowed-authors", "", "disallowedAuthors output of the init handler, the authors of the authors-of commit ranges in JSON format (env DISALLOWED_AUTHORS)")
+	callbackCmd.Flags().StringVar(&cfg.DistinctFrom, "distinct-from", "", "decisionRecord outputs of earlier approval jobs, as a JSON list or one per line, whose approvers may not approve (env DISTINCT_FROM)")
+
+	cancelCmd.Flags().StringVar(&cfg.CancellationReason, "reason", "", "Cancellation reason: CANCELLED or TIMED_OUT (env CANCELLATION_REASON)")
+
+	validateCmd.Flags().StringVarP(&cfg.File, "file", "f", "", "Workflow or job configuration YAML file")
+	validateCmd.Flags().StringVar(&cfg.Approvers, "approvers", "", "Comma separated list of approvers (env APPROVERS)")
+	validateCmd.Flags().StringVar(&cfg.Instructions, "instructions", "", "Instructions for approvers in markdown format (env INSTRUCTIONS)")
+	validateCmd.Flags().StringVar(&cfg.DisallowLaunchedByUser, "disallow-launched-by-user", "", "Prevent the user who started the workflow from approving: true or false (env DISALLOW_LAUNCHED_BY_USER)")
+	validateCmd.Flags().StringVar(&cfg.NotifyAllEligibleUsers, "notify-all-eligible-users", "", "Notify all users who are eligible to approve: true or false (env NOTIFY_ALL_ELIGIBLE_USERS)")
+	validateCmd.Flags().StringVar(&cfg.Inputs, "inputs", "", "approvalInputs definition in YAML format (env INPUTS)")
+	validateCmd.Flags().StringVar(&cfg.DisallowedApprovers, "disallowed-approvers", "", "Comma separated users who may not approve (env DISALLOWED_APPROVERS)")
+	validateCmd.Flags().StringVar(&cfg.DistinctFrom, "distinct-from", "", "decisionRecord outputs of earlier approval jobs (env DISTINCT_FROM)")
+	validateCmd.Flags().StringVar(&cfg.Delegations, "delegations", "", "Approver substitutes, one per line (env DELEGATIONS)")
+	validateCmd.Flags().StringVar(&cfg.DelegationsFile, "delegations-file", "", "File with approver substitutes (env DELEGATIONS_FILE)")
+
+	statusCmd.Flags().StringVar(&cfg.ApprovalID, "id", "", "ID of the approval request")
+	statusCmd.Flags().StringVarP(&cfg.Format, "format", "o", "table", "Output format: table or json")
+
+	listCmd.Flags().BoolVar(&cfg.Pending, "pending", false, "Only list approval requests waiting for a response")
+	listCmd.Flags().StringVarP(&cfg.Format, "format", "o", "table", "Output format: table or json")
+
+	for _, c := range []*cobra.Command{approveCmd, rejectCmd} {
+		c.Flags().StringVar(&cfg.ApprovalID, "id", "", "ID of the approval request")
+		c.Flags().StringVar(&cfg.Comments, "comments", "", "Comments for the requester")
+		c.Flags().StringArrayVar(&cfg.InputValues, "input", nil, "Approval input value as name=value, can be repeated")
+	}
+	rejectCmd.Flags().StringVar(&cfg.ReasonCode, "reason-code", "", "Rejection reason code, one of --rejection-reasons")
+
+	validateCmd.Flags().StringVar(&cfg.ReuseWindow, "reuse-window", "", "How long an approval of the same approval key is reused (env REUSE_WINDOW)")
+	for _, c := range []*cobra.Command{initCmd, callbackCmd, waitCmd, validateCmd} {
+		c.Flags().StringVar(&cfg.ApprovalKey, "approval-key", "", "What is approved, such as an image digest, approvals are saved under it (env APPROVAL_KEY)")
+		c.Flags().StringVar(&cfg.ApprovalStore, "approval-store", "", "File or http(s) URL approvals are saved to and read from, the bearer token of an HTTP store is read from APPROVAL_STORE_TOKEN (env APPROVAL_STORE)")
+	}
+
+	for _, c := range []*cobra.Command{initCmd, callbackCmd, waitCmd, rejectCmd, validateCmd} {
+		c.Flags().StringVar(&cfg.RequireCommentOnReject, "require-comment-on-reject", "", "Refuse rejections without a comment: true or false (env REQUIRE_COMMENT_ON_REJECT, default false)")
+		c.Flags().StringVar(&cfg.MinCommentLength, "min-comment-length", "", "Minimum number of characters of a rejection comment (env MIN_COMMENT_LENGTH)")
+		c.Flags().StringVar(&cfg.RejectionReasons, "rejection-reasons", "", "Comma separated reason codes one of which every rejection has to give (env REJECTION_REASONS)")
+	}
+
+	cmd.AddCommand(initCmd, callbackCmd, cancelCmd, waitCmd, validateCmd, statusCmd, listCmd, approveCmd, rejectCmd)
+}
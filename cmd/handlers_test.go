@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudbees-io/manual-approval/internal/manual_approval"
+)
+
+func Test_subcommands(t *testing.T) {
+	prevArgs := os.Args
+	defer func() {
+		os.Args = prevArgs
+		cfg = manual_approval.Config{}
+	}()
+
+	statusFile := filepath.Join(t.TempDir(), "status")
+
+	tests := []struct {
+		name string
+		args []string
+		env  map[string]string
+		err  string
+	}{
+		{
+			name: "init - wrong argument",
+			args: []string{"manual-approval", "init", "wrong"},
+			err:  "unknown arguments: [wrong]",
+		},
+		{
+			name: "init - wrong --disallow-launched-by-user flag",
+			args: []string{"manual-approval", "init", "--disallow-launched-by-user", "not a boolean"},
+			err:  "strconv.ParseBool: parsing \"not a boolean\": invalid syntax",
+		},
+		{
+			name: "init - flag overrides environment variable",
+			args: []string{"manual-approval", "init", "--notify-all-eligible-users", "not a boolean"},
+			env:  map[string]string{"NOTIFY_ALL_ELIGIBLE_USERS": "true"},
+			err:  "strconv.ParseBool: parsing \"not a boolean\": invalid syntax",
+		},
+		{
+			name: "init - no API_TOKEN with --url flag",
+			args: []string{"manual-approval", "init", "--url", "http://test.com", "--status-file", statusFile},
+			err:  "--token-file flag or API_TOKEN environment variable missing",
+		},
+		{
+			name: "init - missing --token-file",
+			args: []string{"manual-approval", "init", "--url", "http://test.com", "--status-file", statusFile, "--token-file", "/nonexistent/token"},
+			err:  "failed to read API token file: open /nonexistent/token: no such file or directory",
+		},
+		{
+			name: "init - invalid --rejection-reasons flag",
+			args: []string{"manual-approval", "init", "--rejection-reasons", "security,security", "--status-file", statusFile},
+			err:  "invalid REJECTION_REASONS value 'security,security': reason code 'security' is listed more than once",
+		},
+		{
+			name: "callback - no --payload flag",
+			args: []string{"manual-approval", "callback"},
+			err:  "--payload flag or PAYLOAD environment variable missing",
+		},
+		{
+			name: "callback - no URL",
+			args: []string{"manual-approval", "callback", "--status-file", statusFile, "--payload", "{\"status\": \"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED\", \"comments\": \"lgtm\", \"respondedOn\": \"some-time\", \"userName\": \"Some One\"}"},
+			err:  "--url flag or URL environment variable missing",
+		},
+		{
+			name: "cancel - wrong flag",
+			args: []string{"manual-approval", "cancel", "--payload", "{}"},
+			err:  "unknown flag: --payload",
+		},
+		{
+			name: "cancel - no API_TOKEN",
+			args: []string{"manual-approval", "cancel", "--reason", "CANCELLED", "--url", "http://test.com"},
+			err:  "--token-file flag or API_TOKEN environment variable missing",
+		},
+		{
+			name: "handler flag keeps working",
+			args: []string{"manual-approval", "--handler", "cancel"},
+			env:  map[string]string{"CANCELLATION_REASON": "CANCELLED"},
+			err:  "--url flag or URL environment variable missing",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			cfg = manual_approval.Config{}
+			os.Args = tt.args
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer func(k string) {
+					os.Unsetenv(k)
+				}(k)
+			}
+
+			// Run
+			err := cmd.Execute()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -15,8 +16,14 @@ var (
 	cmd = &cobra.Command{
 		Use:   "manual-approval",
 		Short: "Request manual approval from users and teams",
-		Long:  "Request manual approval from users and teams",
-		RunE:  run,
+		Long: `Request manual approval from users and teams
+
+The handlers of the manual approval custom job are available as the init,
+callback and cancel subcommands. Every flag falls back to the environment
+variable the custom job sets, so the same binary can be scripted outside
+of the custom job.`,
+		Args: cobra.ArbitraryArgs,
+		RunE: run,
 	}
 	cfg manual_approval.Config
 )
@@ -42,5 +49,28 @@ func run(command *cobra.Command, args []string) error {
 
 func init() {
 	// Define flags for configuring the Manual Approval
-	cmd.Flags().StringVar(&cfg.Handler, "handler", "", "Handler field allows you to choose particular handler in the manual approval custom job.")
+	cmd.Flags().StringVar(&cfg.Handler, "handler", "", "Handler field allows you to choose particular handler in the manual approval custom job. Prefer the init, callback and cancel subcommands.")
+
+	cmd.PersistentFlags().StringVar(&cfg.URL, "url", "", "Platform API URL (env URL)")
+	cmd.PersistentFlags().StringVar(&cfg.TokenFile, "token-file", "", "File containing the platform API token, re-read for every request (env API_TOKEN_FILE, otherwise the token is read from API_TOKEN)")
+	cmd.PersistentFlags().StringVar(&cfg.OAuthTokenURL, "oauth-token-url", "", "OAuth2 token endpoint for a client credentials exchange instead of a static token (env OAUTH_TOKEN_URL, the client secret is read from OAUTH_CLIENT_SECRET)")
+	cmd.PersistentFlags().StringVar(&cfg.OAuthClientID, "oauth-client-id", "", "OAuth2 client ID (env OAUTH_CLIENT_ID)")
+	cmd.PersistentFlags().StringVar(&cfg.OAuthScopes, "oauth-scopes", "", "Space separated OAuth2 scopes (env OAUTH_SCOPES)")
+	cmd.PersistentFlags().StringVar(&cfg.CAFile, "ca-file", "", "PEM bundle of certificate authorities trusted in addition to the system roots (env CA_FILE)")
+	cmd.PersistentFlags().StringVar(&cfg.ClientCertFile, "client-cert", "", "PEM client certificate for mTLS (env CLIENT_CERT_FILE)")
+	cmd.PersistentFlags().StringVar(&cfg.ClientKeyFile, "client-key", "", "PEM client certificate key for mTLS (env CLIENT_KEY_FILE)")
+	cmd.PersistentFlags().StringVar(&cfg.Proxy, "proxy", "", "Proxy URL for platform API requests (env HTTPS_PROXY, HTTP_PROXY)")
+	cmd.PersistentFlags().StringVar(&cfg.NoProxy, "no-proxy", "", "Comma separated hosts, domains and CIDRs reached without the proxy (env NO_PROXY)")
+	cmd.PersistentFlags().BoolVar(&cfg.DisableHTTP2, "disable-http2", false, "Limit platform API requests to HTTP/1.1")
+	cmd.PersistentFlags().DurationVar(&cfg.ConnectTimeout, "connect-timeout", 30*time.Second, "Timeout for establishing a connection to the platform API")
+	cmd.PersistentFlags().DurationVar(&cfg.TLSHandshakeTimeout, "tls-handshake-timeout", 10*time.Second, "Timeout for the TLS handshake with the platform API")
+	cmd.PersistentFlags().DurationVar(&cfg.RequestTimeout, "http-timeout", 150*time.Second, "Timeout for a whole platform API request including reading the response")
+	cmd.PersistentFlags().StringVar(&cfg.OutputsDir, "outputs-dir", "", "Directory the job outputs are written to (env CLOUDBEES_OUTPUTS)")
+	cmd.PersistentFlags().StringVar(&cfg.InputOutputs, "input-outputs", "", "Also write every approval input value to its own input_<name> output: true or false (env INPUT_OUTPUTS, default false)")
+	cmd.PersistentFlags().StringVar(&cfg.StatusFile, "status-file", "", "File the job status is written to (env CLOUDBEES_STATUS)")
+	cmd.PersistentFlags().StringVar(&cfg.OutputMode, "output-mode", "", "Output mode for instructions and input values: html, ansi or plain (env OUTPUT_MODE). Defaults to ansi when stdout is a terminal and html otherwise.")
+	cmd.PersistentFlags().StringVar(&cfg.BackendType, "backend", "", "Approval backend: platform, tty or file (env APPROVAL_BACKEND, default platform)")
+	cmd.PersistentFlags().StringVar(&cfg.BackendDir, "backend-dir", "", "Directory of the request and response files of the file backend (env APPROVAL_DIR)")
+	cmd.PersistentFlags().BoolVar(&cfg.DryRun, "dry-run", false, "Log platform API requests instead of sending them and write the outputs and status to a temporary directory")
+	cmd.PersistentFlags().BoolVar(&cfg.Debug, "debug", false, "Enable debug logging (env DEBUG)")
 }
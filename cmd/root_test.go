@@ -45,7 +45,7 @@ func Test_arguments(t *testing.T) {
 			name: "init - no URL environment variable",
 			args: []string{"manual-approval", "--handler", "init"},
 			env:  map[string]string{"CLOUDBEES_STATUS": "/tmp/fake-status" + strconv.Itoa(time.Now().Nanosecond())},
-			err:  "URL environment variable missing",
+			err:  "--url flag or URL environment variable missing",
 		},
 		{
 			name: "init - wrong DISALLOW_LAUNCHED_BY_USER environment variable",
@@ -63,51 +63,51 @@ func Test_arguments(t *testing.T) {
 			name: "init - no API_TOKEN environment variable",
 			args: []string{"manual-approval", "--handler", "init"},
 			env:  map[string]string{"URL": "http://test.com", "CLOUDBEES_STATUS": "/tmp/fake-status.out" + strconv.Itoa(time.Now().Nanosecond())},
-			err:  "API_TOKEN environment variable missing",
+			err:  "--token-file flag or API_TOKEN environment variable missing",
 		},
 		{
 			name: "init - no CLOUDBEES_STATUS environment variable",
 			args: []string{"manual-approval", "--handler", "init"},
 			env:  map[string]string{"URL": "http://test.com", "API_TOKEN": "12345"},
-			err:  "CLOUDBEES_STATUS environment variable missing",
+			err:  "--status-file flag or CLOUDBEES_STATUS environment variable missing",
 		},
 		{
 			name: "callback - no PAYLOAD environment variable",
 			args: []string{"manual-approval", "--handler", "callback"},
 			env:  map[string]string{},
-			err:  "PAYLOAD environment variable missing",
+			err:  "--payload flag or PAYLOAD environment variable missing",
 		},
 		{
 			name: "callback - no URL environment variable",
 			args: []string{"manual-approval", "--handler", "callback"},
 			env: map[string]string{"PAYLOAD": "{\"status\": \"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED\", \"comments\": \"lgtm\", \"respondedOn\": \"some-time\", \"userName\": \"Some One\"}",
 				"CLOUDBEES_STATUS": "/tmp/fake-status.out" + strconv.Itoa(time.Now().Nanosecond())},
-			err: "URL environment variable missing",
+			err: "--url flag or URL environment variable missing",
 		},
 		{
 			name: "callback - no API_TOKEN environment variable",
 			args: []string{"manual-approval", "--handler", "callback"},
 			env: map[string]string{"PAYLOAD": "{\"status\": \"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED\", \"comments\": \"lgtm\", \"respondedOn\": \"some-time\", \"userName\": \"Some One\"}",
 				"URL": "http://test.com", "CLOUDBEES_STATUS": "/tmp/fake-status.out" + strconv.Itoa(time.Now().Nanosecond())},
-			err: "API_TOKEN environment variable missing",
+			err: "--token-file flag or API_TOKEN environment variable missing",
 		},
 		{
 			name: "cancel - no CANCELLATION_REASON environment variable",
 			args: []string{"manual-approval", "--handler", "cancel"},
 			env:  map[string]string{},
-			err:  "CANCELLATION_REASON environment variable missing",
+			err:  "--reason flag or CANCELLATION_REASON environment variable missing",
 		},
 		{
 			name: "cancel - no URL environment variable",
 			args: []string{"manual-approval", "--handler", "cancel"},
 			env:  map[string]string{"CANCELLATION_REASON": "test reason"},
-			err:  "URL environment variable missing",
+			err:  "--url flag or URL environment variable missing",
 		},
 		{
 			name: "cancel - no API_TOKEN environment variable",
 			args: []string{"manual-approval", "--handler", "cancel"},
 			env:  map[string]string{"CANCELLATION_REASON": "test reason", "URL": "http://test.com"},
-			err:  "API_TOKEN environment variable missing",
+			err:  "--token-file flag or API_TOKEN environment variable missing",
 		},
 	}
 	for _, tt := range tests {
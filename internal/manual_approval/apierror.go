@@ -0,0 +1,90 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIError is a platform API response with a status code other than 200
+type APIError struct {
+	StatusCode int
+	Status     string
+	Method     string
+	URL        string
+	Path       string
+
+	// RequestID is the X-Request-Id response header, quoted when asking the platform team for help
+	RequestID string
+
+	// Code and Message are parsed from the platform error body, they are empty when the body is
+	// not a JSON error
+	Code    string
+	Message string
+
+	// Body is the raw response body
+	Body string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("failed to send event: \n%s %s\nHTTP/%d %s\n", e.Method, e.URL, e.StatusCode, e.Status)
+}
+
+// Summary describes the error in a single line, preferring the message of the platform
+func (e *APIError) Summary() string {
+	message := e.Message
+	if message == "" {
+		message = strings.TrimSpace(http.StatusText(e.StatusCode))
+	}
+	details := []string{fmt.Sprintf("HTTP %d", e.StatusCode)}
+	if e.Code != "" {
+		details = append(details, "code "+e.Code)
+	}
+	if e.RequestID != "" {
+		details = append(details, "request ID "+e.RequestID)
+	}
+	return fmt.Sprintf("%s (%s)", message, strings.Join(details, ", "))
+}
+
+// newAPIError parses the platform error body of the response
+func newAPIError(resp *http.Response, method string, requestURL string, path string, body string) *APIError {
+	apiErr := &APIError{
+		StatusCode: resp.StatusCode,
+		Status:     resp.Status,
+		Method:     method,
+		URL:        requestURL,
+		Path:       path,
+		RequestID:  resp.Header.Get("X-Request-Id"),
+		Body:       body,
+	}
+
+	// The platform answers {"code": ..., "message": ...}, some gateways nest it in "error"
+	var parsed struct {
+		Code    json.RawMessage `json:"code"`
+		Message string          `json:"message"`
+		Error   json.RawMessage `json:"error"`
+	}
+	if err := json.Unmarshal([]byte(body), &parsed); err != nil {
+		return apiErr
+	}
+	if len(parsed.Error) > 0 && parsed.Error[0] == '{' {
+		_ = json.Unmarshal(parsed.Error, &parsed)
+	} else if len(parsed.Error) > 0 && parsed.Message == "" {
+		_ = json.Unmarshal(parsed.Error, &parsed.Message)
+	}
+	apiErr.Message = parsed.Message
+	apiErr.Code = strings.Trim(string(parsed.Code), `"`)
+	return apiErr
+}
+
+// errorMessage returns the message of the error for the job status, the parsed platform message
+// for API errors
+func errorMessage(err error) string {
+	var apiErr *APIError
+	if errors.As(err, &apiErr) && apiErr.Message != "" {
+		return apiErr.Summary()
+	}
+	return err.Error()
+}
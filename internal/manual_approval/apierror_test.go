@@ -0,0 +1,196 @@
+package manual_approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_APIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		requestID string
+		body      string
+		code      string
+		message   string
+		summary   string
+		exitCode  int
+	}{
+		{
+			name:      "platform error",
+			status:    http.StatusBadRequest,
+			requestID: "req-123",
+			body:      `{"code":"INVALID_ARGUMENT","message":"approvers must not be empty"}`,
+			code:      "INVALID_ARGUMENT",
+			message:   "approvers must not be empty",
+			summary:   "approvers must not be empty (HTTP 400, code INVALID_ARGUMENT, request ID req-123)",
+			exitCode:  ExitAPIClient,
+		},
+		{
+			name:     "numeric code",
+			status:   http.StatusNotFound,
+			body:     `{"code":5,"message":"approval request not found","details":[]}`,
+			code:     "5",
+			message:  "approval request not found",
+			summary:  "approval request not found (HTTP 404, code 5)",
+			exitCode: ExitAPIClient,
+		},
+		{
+			name:     "nested error",
+			status:   http.StatusForbidden,
+			body:     `{"error":{"code":"PERMISSION_DENIED","message":"user may not approve"}}`,
+			code:     "PERMISSION_DENIED",
+			message:  "user may not approve",
+			summary:  "user may not approve (HTTP 403, code PERMISSION_DENIED)",
+			exitCode: ExitAPIClient,
+		},
+		{
+			name:     "error string",
+			status:   http.StatusServiceUnavailable,
+			body:     `{"error":"maintenance"}`,
+			message:  "maintenance",
+			summary:  "maintenance (HTTP 503)",
+			exitCode: ExitAPIServer,
+		},
+		{
+			name:     "not JSON",
+			status:   http.StatusBadGateway,
+			body:     "<html>bad gateway</html>",
+			exitCode: ExitAPIServer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.requestID != "" {
+					w.Header().Set("X-Request-Id", tt.requestID)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			t.Setenv("API_TOKEN", "test")
+
+			// Run
+			c := Config{URL: server.URL}
+			resp, err := c.post(context.Background(), "/v1/workflows/approval", map[string]interface{}{})
+
+			// Verify
+			require.Equal(t, tt.body, resp)
+			var apiErr *APIError
+			require.True(t, errors.As(err, &apiErr))
+			require.Equal(t, fmt.Sprintf("failed to send event: \nPOST %s/v1/workflows/approval\nHTTP/%d %d %s\n", server.URL, tt.status, tt.status, http.StatusText(tt.status)), err.Error())
+			require.Equal(t, tt.status, apiErr.StatusCode)
+			require.Equal(t, "POST", apiErr.Method)
+			require.Equal(t, "/v1/workflows/approval", apiErr.Path)
+			require.Equal(t, tt.requestID, apiErr.RequestID)
+			require.Equal(t, tt.code, apiErr.Code)
+			require.Equal(t, tt.message, apiErr.Message)
+			require.Equal(t, tt.body, apiErr.Body)
+			if tt.summary == "" {
+				require.Equal(t, err.Error(), errorMessage(err))
+			} else {
+				require.Equal(t, tt.summary, errorMessage(err))
+			}
+			require.Equal(t, tt.exitCode, ExitCode(err))
+		})
+	}
+}
+
+func Test_APIErrorInStatus(t *testing.T) {
+	// Prepare
+	server, _ := newMockPlatform(t, map[string]string{})
+	t.Setenv("API_TOKEN", "test")
+	dir := t.TempDir()
+
+	// Run
+	c := Config{
+		URL:        server.URL,
+		StatusFile: filepath.Join(dir, "status"),
+		Output: &MockStdOut{
+			MockPrintf: func(format string, a ...any) {},
+		},
+	}
+	err := c.init()
+
+	// Verify
+	require.Error(t, err)
+	require.Equal(t, ExitAPIClient, ExitCode(err))
+	requireStatusFile(t, "{\"message\":\"Failed to initialize workflow manual approval request: 'not found (HTTP 404)'\",\"status\":\"FAILED\"}", filepath.Join(dir, "status"))
+}
+
+func Test_ExitCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		err      error
+		exitCode int
+	}{
+		{
+			name:     "success",
+			exitCode: ExitOK,
+		},
+		{
+			name:     "failure",
+			err:      fmt.Errorf("failed to write to status"),
+			exitCode: ExitFailure,
+		},
+		{
+			name:     "exit error",
+			err:      &ExitError{Code: ExitRejected, Err: fmt.Errorf("rejected")},
+			exitCode: ExitRejected,
+		},
+		{
+			name:     "cancelled",
+			err:      fmt.Errorf("Get \"http://test.com\": %w", context.Canceled),
+			exitCode: ExitCancelled,
+		},
+		{
+			name:     "configuration",
+			err:      (&Config{Format: "yaml"}).checkFormat(),
+			exitCode: ExitConfig,
+		},
+		{
+			name:     "missing environment variable",
+			err:      (&Config{}).writeStatus("FAILED", ""),
+			exitCode: ExitConfig,
+		},
+		{
+			name:     "invalid boolean input",
+			err:      func() error { _, err := (&Config{NotifyAllEligibleUsers: "maybe"}).requestApproval(); return err }(),
+			exitCode: ExitConfig,
+		},
+		{
+			name:     "invalid input value",
+			err:      func() error { _, err := parseInputValues([]string{"replicas"}); return err }(),
+			exitCode: ExitValidation,
+		},
+		{
+			name:     "invalid approvalInputs",
+			err:      func() error { _, err := parseApprovalInputs("in1:\n  type: date"); return err }(),
+			exitCode: ExitValidation,
+		},
+		{
+			name:     "API client error",
+			err:      fmt.Errorf("wrapped: %w", &APIError{StatusCode: http.StatusUnauthorized}),
+			exitCode: ExitAPIClient,
+		},
+		{
+			name:     "API server error",
+			err:      &APIError{StatusCode: http.StatusInternalServerError},
+			exitCode: ExitAPIServer,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.exitCode, ExitCode(tt.err))
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package manual_approval
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+)
+
+// ApprovalBackend stores manual approval requests and the responses of the approvers
+type ApprovalBackend interface {
+	// Create creates the approval request described by the request body of the init handler
+	Create(ctx context.Context, request map[string]interface{}) (*CreateManualApprovalResponse, error)
+
+	// UpdateStatus records the approver decision given in the callback handler payload format
+	UpdateStatus(ctx context.Context, decision map[string]interface{}) error
+
+	// Get returns the approval request with the response once the approver has answered
+	Get(ctx context.Context, id string) (*ApprovalRequest, error)
+
+	// Cancel ends the approval request with the ABORTED or TIMED_OUT status. The ID is optional,
+	// backends use the request of the running job without it.
+	Cancel(ctx context.Context, id string, status string) error
+}
+
+// backend returns the configured approval backend, the platform API is used unless another one
+// is selected with the backend type or the APPROVAL_BACKEND environment variable
+func (k *Config) backend() (ApprovalBackend, error) {
+	if k.Backend != nil {
+		return k.Backend, nil
+	}
+
+	switch backendType := valueOrEnv(k.BackendType, "APPROVAL_BACKEND"); backendType {
+	case "", BackendPlatform:
+		k.Backend = &platformBackend{k}
+	case BackendTTY:
+		k.Backend = &ttyBackend{k: k}
+	case BackendFile:
+		backend, err := newFileBackend(k)
+		if err != nil {
+			return nil, err
+		}
+		k.Backend = backend
+	default:
+		return nil, configErrorf("unsupported approval backend: %s", backendType)
+	}
+	return k.Backend, nil
+}
+
+// ctx returns the context of the run, a background context is used when it is not set
+func (k *Config) ctx() context.Context {
+	if k.Context == nil {
+		return context.Background()
+	}
+	return k.Context
+}
+
+// platformBackend is the approval backend of the platform REST API
+type platformBackend struct {
+	k *Config
+}
+
+func (p *platformBackend) Create(ctx context.Context, request map[string]interface{}) (*CreateManualApprovalResponse, error) {
+	resp, err := p.k.post(ctx, "/v1/workflows/approval", request)
+	if err != nil {
+		p.logError(ctx, resp, err)
+		return nil, err
+	}
+	debugf("Response: '%s'\n", resp)
+
+	parsedResp := &CreateManualApprovalResponse{}
+	if err := json.Unmarshal([]byte(resp), parsedResp); err != nil {
+		return nil, err
+	}
+	return parsedResp, nil
+}
+
+func (p *platformBackend) UpdateStatus(ctx context.Context, decision map[string]interface{}) error {
+	resp, err := p.k.post(ctx, approvalStatusPath, decision)
+	if err != nil {
+		p.logError(ctx, resp, err)
+		return err
+	}
+	debugf("Response: '%s'\n", resp)
+	return nil
+}
+
+func (p *platformBackend) Get(ctx context.Context, id string) (*ApprovalRequest, error) {
+	resp, err := p.k.get(ctx, "/v1/workflows/approval/"+url.PathEscape(id), nil)
+	if err != nil {
+		p.logError(ctx, resp, err)
+		return nil, err
+	}
+	debugf("Response: '%s'\n", resp)
+
+	approval := &ApprovalRequest{}
+	if err := decodeJSON([]byte(resp), approval); err != nil {
+		return nil, err
+	}
+	return approval, nil
+}
+
+func (p *platformBackend) Cancel(ctx context.Context, id string, status string) error {
+	body := map[string]interface{}{
+		"status": "UPDATE_MANUAL_APPROVAL_STATUS_" + status,
+	}
+	if id != "" {
+		body["id"] = id
+	}
+	return p.UpdateStatus(ctx, body)
+}
+
+func (p *platformBackend) logError(ctx context.Context, resp string, err error) {
+	// Requests cut short by the cancelled run did not fail on the platform side
+	if ctx.Err() != nil {
+		return
+	}
+	p.k.Output.Printf("ERROR: API call failed with error: '%s'\n", err)
+	p.k.Output.Printf("ERROR: API response: '%s'\n", resp)
+}
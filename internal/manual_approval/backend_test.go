@@ -0,0 +1,222 @@
+package manual_approval
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeBackend keeps approval requests in memory
+type fakeBackend struct {
+	created   []map[string]interface{}
+	decisions []map[string]interface{}
+	cancelled []string
+	approvals map[string]*ApprovalRequest
+	err       error
+}
+
+func (f *fakeBackend) Create(_ context.Context, request map[string]interface{}) (*CreateManualApprovalResponse, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	f.created = append(f.created, request)
+	return &CreateManualApprovalResponse{ID: "a-1", Approvers: []Approvers{{UserName: "testUserName"}}}, nil
+}
+
+func (f *fakeBackend) UpdateStatus(_ context.Context, decision map[string]interface{}) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.decisions = append(f.decisions, decision)
+	return nil
+}
+
+func (f *fakeBackend) Get(_ context.Context, id string) (*ApprovalRequest, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	approval, ok := f.approvals[id]
+	if !ok {
+		return nil, fmt.Errorf("approval request %s not found", id)
+	}
+	return approval, nil
+}
+
+func (f *fakeBackend) Cancel(_ context.Context, id string, status string) error {
+	f.cancelled = append(f.cancelled, id+" "+status)
+	return nil
+}
+
+func Test_backend(t *testing.T) {
+	tests := []struct {
+		name        string
+		backendType string
+		backend     ApprovalBackend
+		expected    interface{}
+		err         string
+	}{
+		{
+			name:     "platform by default",
+			expected: &platformBackend{},
+		},
+		{
+			name:        "tty",
+			backendType: BackendTTY,
+			expected:    &ttyBackend{},
+		},
+		{
+			name:     "configured backend",
+			backend:  &fakeBackend{},
+			expected: &fakeBackend{},
+		},
+		{
+			name:        "unsupported",
+			backendType: "carrier-pigeon",
+			err:         "unsupported approval backend: carrier-pigeon",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			c := Config{BackendType: tt.backendType, Backend: tt.backend}
+			backend, err := c.backend()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.IsType(t, tt.expected, backend)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func Test_handlersWithFakeBackend(t *testing.T) {
+	tests := []struct {
+		name         string
+		config       Config
+		run          func(c *Config) error
+		approvals    map[string]*ApprovalRequest
+		backendErr   error
+		created      int
+		decision     string
+		cancelled    []string
+		statusInFile string
+		output       string
+		err          string
+	}{
+		{
+			name:         "init",
+			config:       Config{Approvers: "user@mail.com", Inputs: "reason:\n  type: string"},
+			run:          (*Config).init,
+			created:      1,
+			statusInFile: "{\"message\":\"Waiting for approval from approvers\",\"status\":\"PENDING_APPROVAL\"}",
+			output:       "Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:         "init fails",
+			run:          (*Config).init,
+			backendErr:   fmt.Errorf("backend down"),
+			statusInFile: "{\"message\":\"Failed to initialize workflow manual approval request: 'backend down'\",\"status\":\"FAILED\"}",
+			err:          "backend down",
+		},
+		{
+			name:         "callback",
+			config:       Config{Payload: `{"status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"lgtm","respondedOn":"2009-11-10T23:00:00Z","userName":"testUserName"}`},
+			run:          (*Config).callback,
+			decision:     "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED",
+			statusInFile: "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"APPROVED\"}",
+			output:       "Approved by testUserName on 2009-11-10T23:00:00Z with comments:\nlgtm\n",
+		},
+		{
+			name:      "cancel",
+			config:    Config{CancellationReason: "CANCELLED"},
+			run:       (*Config).cancel,
+			cancelled: []string{" ABORTED"},
+			output:    "Workflow aborted by user\nCancelling the manual approval request\n",
+		},
+		{
+			name:   "reject",
+			config: Config{ApprovalID: "a-1", Comments: "not now"},
+			run:    func(c *Config) error { return c.respond(false) },
+			approvals: map[string]*ApprovalRequest{
+				"a-1": {ID: "a-1", Status: ApprovalStatusPending},
+			},
+			decision: "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			output:   "Rejected a-1\n",
+		},
+		{
+			name: "wait until approved",
+			run:  (*Config).wait,
+			approvals: map[string]*ApprovalRequest{
+				"a-1": {ID: "a-1", Status: ApprovalStatusApproved, UserName: "testUserName", RespondedOn: "2009-11-10T23:00:00Z", Comments: "lgtm"},
+			},
+			created:      1,
+			statusInFile: "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"APPROVED\"}",
+			output:       "Waiting for approval from one of the following: testUserName\nApproved by testUserName on 2009-11-10T23:00:00Z with comments:\nlgtm\n",
+		},
+		{
+			name:         "wait until timed out",
+			config:       Config{Timeout: time.Nanosecond},
+			run:          (*Config).wait,
+			approvals:    map[string]*ApprovalRequest{"a-1": {ID: "a-1", Status: ApprovalStatusPending}},
+			created:      1,
+			cancelled:    []string{"a-1 TIMED_OUT"},
+			statusInFile: "{\"message\":\"Workflow approval response was not received within allotted time.\",\"status\":\"FAILED\"}",
+			output:       "Waiting for approval from one of the following: testUserName\nWorkflow timed out\nWorkflow approval response was not received within allotted time.\n",
+			err:          "approval request a-1: Workflow approval response was not received within allotted time.",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{approvals: tt.approvals, err: tt.backendErr}
+			var testOutput strings.Builder
+
+			// Run
+			c := tt.config
+			c.Backend = backend
+			c.OutputMode = OutputModeHTML
+			c.OutputsDir = dir
+			c.StatusFile = filepath.Join(dir, "status")
+			c.PollInterval = time.Millisecond
+			c.Output = &MockStdOut{
+				MockPrintf: func(format string, a ...any) {
+					testOutput.WriteString(fmt.Sprintf(format, a...))
+				},
+				MockPrintln: func(a ...any) {
+					testOutput.WriteString(fmt.Sprintln(a...))
+				},
+			}
+			err := tt.run(&c)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+			require.Equal(t, tt.output, testOutput.String())
+			require.Len(t, backend.created, tt.created)
+			require.Equal(t, tt.cancelled, backend.cancelled)
+			if tt.decision == "" {
+				require.Empty(t, backend.decisions)
+			} else {
+				require.Len(t, backend.decisions, 1)
+				require.Equal(t, tt.decision, backend.decisions[0]["status"])
+			}
+			if tt.statusInFile != "" {
+				requireStatusFile(t, tt.statusInFile, filepath.Join(dir, "status"))
+			}
+		})
+	}
+}
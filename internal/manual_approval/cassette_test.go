@@ -0,0 +1,290 @@
+package manual_approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/stretchr/testify/require"
+)
+
+var record = flag.Bool("record", false, "record the HTTP cassettes against the platform API at RECORD_URL")
+
+// cassetteURL is the platform API URL the requests of a replayed cassette are sent to
+const cassetteURL = "http://test.com"
+
+// cassette is an HttpClient replaying the platform API interactions recorded in a file in order.
+// Requests are matched on method, path, query and normalized body. When recording, the requests are
+// sent to the platform API instead and the file is rewritten at the end of the test.
+type cassette struct {
+	file         string
+	recording    bool
+	baseURL      string
+	client       HttpClient
+	interactions []interaction
+	next         int
+}
+
+type interaction struct {
+	Request  recordedRequest  `json:"request"`
+	Response recordedResponse `json:"response"`
+}
+
+type recordedRequest struct {
+	Method string          `json:"method"`
+	Path   string          `json:"path"`
+	Query  string          `json:"query,omitempty"`
+	Body   json.RawMessage `json:"body,omitempty"`
+}
+
+type recordedResponse struct {
+	StatusCode int               `json:"statusCode"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       json.RawMessage   `json:"body,omitempty"`
+	Text       string            `json:"text,omitempty"`
+}
+
+// newCassette opens testdata/cassettes/<name>.json, it is recorded against RECORD_URL with -record
+func newCassette(t *testing.T, name string) *cassette {
+	t.Helper()
+	file := filepath.Join("testdata", "cassettes", name+".json")
+	if !*record {
+		t.Setenv("API_TOKEN", "test")
+	}
+	return openCassette(t, file, *record, os.Getenv("RECORD_URL"))
+}
+
+func openCassette(t *testing.T, file string, recording bool, baseURL string) *cassette {
+	t.Helper()
+	c := &cassette{file: file, recording: recording, baseURL: cassetteURL}
+	if recording {
+		require.NotEmpty(t, baseURL, "RECORD_URL must be set to record %s", file)
+		c.baseURL = baseURL
+		c.client = &http.Client{}
+		t.Cleanup(func() {
+			data, err := json.MarshalIndent(c.interactions, "", "  ")
+			require.NoError(t, err)
+			require.NoError(t, os.MkdirAll(filepath.Dir(file), 0755))
+			require.NoError(t, os.WriteFile(file, append(data, '\n'), 0644))
+		})
+		return c
+	}
+
+	data, err := os.ReadFile(file)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &c.interactions))
+	t.Cleanup(func() {
+		if err := c.unused(); err != nil {
+			t.Error(err)
+		}
+	})
+	return c
+}
+
+// url is the platform API URL of the configuration under test
+func (c *cassette) url() string {
+	return c.baseURL
+}
+
+func (c *cassette) Do(req *http.Request) (*http.Response, error) {
+	sent, err := c.request(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.recording {
+		resp, err := c.client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+		recorded, err := recordResponse(resp)
+		if err != nil {
+			return nil, err
+		}
+		c.interactions = append(c.interactions, interaction{Request: sent, Response: recorded})
+		return recorded.response(req), nil
+	}
+
+	if c.next >= len(c.interactions) {
+		return nil, fmt.Errorf("cassette %s: unexpected request %d:\n%s", c.file, c.next+1, sent)
+	}
+	expected := c.interactions[c.next]
+	if diff := requestDiff(expected.Request, sent); diff != "" {
+		return nil, fmt.Errorf("cassette %s: request %d does not match the recording:\n%s", c.file, c.next+1, diff)
+	}
+	c.next++
+	return expected.Response.response(req), nil
+}
+
+// unused reports the recorded interactions the test did not replay
+func (c *cassette) unused() error {
+	if c.next < len(c.interactions) {
+		return fmt.Errorf("cassette %s: %d of %d recorded requests were not sent, next is:\n%s",
+			c.file, len(c.interactions)-c.next, len(c.interactions), c.interactions[c.next].Request)
+	}
+	return nil
+}
+
+// request is the recording of a request, the path is relative to the platform API URL
+func (c *cassette) request(req *http.Request) (recordedRequest, error) {
+	base, err := url.Parse(c.baseURL)
+	if err != nil {
+		return recordedRequest{}, err
+	}
+	recorded := recordedRequest{
+		Method: req.Method,
+		Path:   "/" + strings.TrimPrefix(strings.TrimPrefix(req.URL.Path, strings.TrimSuffix(base.Path, "/")), "/"),
+		Query:  req.URL.RawQuery,
+	}
+	if req.GetBody != nil {
+		reader, err := req.GetBody()
+		if err != nil {
+			return recordedRequest{}, err
+		}
+		body, err := io.ReadAll(reader)
+		if err != nil {
+			return recordedRequest{}, err
+		}
+		recorded.Body = normalizeBody(body)
+	}
+	return recorded, nil
+}
+
+func (r recordedRequest) String() string {
+	s := r.Method + " " + r.Path
+	if r.Query != "" {
+		s += "?" + r.Query
+	}
+	if len(r.Body) > 0 {
+		s += "\n" + string(normalizeBody(r.Body))
+	}
+	return s + "\n"
+}
+
+// normalizeBody indents JSON bodies with sorted keys so they compare and diff by value, other bodies
+// are kept as a JSON string
+func normalizeBody(body []byte) json.RawMessage {
+	if len(body) == 0 {
+		return nil
+	}
+	var value interface{}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		value = string(body)
+	}
+	normalized, _ := json.MarshalIndent(value, "", "  ")
+	return normalized
+}
+
+// requestDiff is a unified diff of the recorded and the sent request, empty when they match
+func requestDiff(recorded recordedRequest, sent recordedRequest) string {
+	if recorded.String() == sent.String() {
+		return ""
+	}
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(recorded.String()),
+		B:        difflib.SplitLines(sent.String()),
+		FromFile: "recorded",
+		ToFile:   "sent",
+		Context:  3,
+	})
+	return diff
+}
+
+func recordResponse(resp *http.Response) (recordedResponse, error) {
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return recordedResponse{}, err
+	}
+	recorded := recordedResponse{StatusCode: resp.StatusCode}
+	for _, name := range []string{"Content-Type", "Retry-After", "X-Request-Id"} {
+		if value := resp.Header.Get(name); value != "" {
+			if recorded.Header == nil {
+				recorded.Header = map[string]string{}
+			}
+			recorded.Header[name] = value
+		}
+	}
+	if json.Valid(body) {
+		recorded.Body = normalizeBody(body)
+	} else {
+		recorded.Text = string(body)
+	}
+	return recorded, nil
+}
+
+func (r recordedResponse) response(req *http.Request) *http.Response {
+	body := r.Text
+	if len(r.Body) > 0 {
+		body = string(r.Body)
+	}
+	header := http.Header{}
+	for name, value := range r.Header {
+		header.Set(name, value)
+	}
+	return &http.Response{
+		StatusCode: r.StatusCode,
+		Status:     fmt.Sprintf("%d %s", r.StatusCode, http.StatusText(r.StatusCode)),
+		Header:     header,
+		Body:       io.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}
+}
+
+func Test_cassette(t *testing.T) {
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "req-1")
+		_, _ = io.WriteString(w, `{"id":"a-1","approvers":[{"userName":"jane"}]}`)
+	}))
+	defer platform.Close()
+	t.Setenv("API_TOKEN", "test")
+	file := filepath.Join(t.TempDir(), "create.json")
+	body := map[string]interface{}{"approvers": []string{"jane"}, "notifyEligibleUsers": false}
+
+	t.Run("record", func(t *testing.T) {
+		c := Config{URL: platform.URL, Client: openCassette(t, file, true, platform.URL)}
+
+		resp, err := c.post(c.ctx(), "/v1/workflows/approval", body)
+
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"a-1","approvers":[{"userName":"jane"}]}`, resp)
+	})
+
+	t.Run("replay", func(t *testing.T) {
+		cassette := openCassette(t, file, false, "")
+		c := Config{URL: cassette.url(), Client: cassette}
+
+		resp, err := c.post(c.ctx(), "/v1/workflows/approval", body)
+
+		require.NoError(t, err)
+		require.JSONEq(t, `{"id":"a-1","approvers":[{"userName":"jane"}]}`, resp)
+		require.NoError(t, cassette.unused())
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		cassette := &cassette{file: file, baseURL: cassetteURL}
+		data, err := os.ReadFile(file)
+		require.NoError(t, err)
+		require.NoError(t, json.Unmarshal(data, &cassette.interactions))
+		c := Config{URL: cassette.url(), Client: cassette}
+
+		_, err = c.post(c.ctx(), "/v1/workflows/approval", map[string]interface{}{"approvers": []string{"joe"}, "notifyEligibleUsers": false})
+
+		require.ErrorContains(t, err, "request 1 does not match the recording:\n--- recorded\n+++ sent\n")
+		require.ErrorContains(t, err, "\n-    \"jane\"\n+    \"joe\"\n")
+		require.EqualError(t, cassette.unused(), fmt.Sprintf("cassette %s: 1 of 1 recorded requests were not sent, next is:\nPOST /v1/workflows/approval\n{\n  \"approvers\": [\n    \"jane\"\n  ],\n  \"notifyEligibleUsers\": false\n}\n", file))
+	})
+}
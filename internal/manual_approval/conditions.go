@@ -0,0 +1,228 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// InputCondition makes an input apply only when another input has one of the given values
+type InputCondition struct {
+	Input  string
+	Values []string
+
+	// Line of the condition in the approvalInputs YAML document
+	Line int
+}
+
+// parseWhen parses the when attribute, a mapping of input names to a value or a list of values
+func parseWhen(node *yaml.Node, fail func(*yaml.Node, string, ...any) *InputError) ([]InputCondition, InputErrors) {
+	if node.Kind != yaml.MappingNode {
+		return nil, InputErrors{fail(node, "when must be a mapping of input names to values")}
+	}
+
+	var conditions []InputCondition
+	var errs InputErrors
+	for i := 0; i < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		condition := InputCondition{Input: key.Value, Line: key.Line}
+		switch value.Kind {
+		case yaml.ScalarNode:
+			condition.Values = []string{value.Value}
+		case yaml.SequenceNode:
+			for _, item := range value.Content {
+				if item.Kind != yaml.ScalarNode {
+					errs = append(errs, fail(item, "when values of '%s' must be scalars", key.Value))
+					continue
+				}
+				condition.Values = append(condition.Values, item.Value)
+			}
+		default:
+			errs = append(errs, fail(value, "when value of '%s' must be a value or a list of values", key.Value))
+			continue
+		}
+		conditions = append(conditions, condition)
+	}
+	return conditions, errs
+}
+
+// checkConditions rejects conditions on unknown inputs, values the referenced input can never have
+// and inputs that depend on themselves
+func checkConditions(inputs []ApprovalInput) InputErrors {
+	byName := map[string]*ApprovalInput{}
+	for i := range inputs {
+		byName[inputs[i].Name] = &inputs[i]
+	}
+
+	var errs InputErrors
+	for _, input := range inputs {
+		for _, condition := range input.When {
+			fail := func(format string, a ...any) {
+				errs = append(errs, &InputError{Line: condition.Line, Input: input.Name, Message: "when: " + fmt.Sprintf(format, a...)})
+			}
+			referenced, ok := byName[condition.Input]
+			switch {
+			case condition.Input == input.Name:
+				fail("input refers to itself")
+				continue
+			case !ok:
+				fail("unknown input '%s'", condition.Input)
+				continue
+			}
+			for _, value := range condition.Values {
+				if _, err := referenced.conditionValue(value); err != nil {
+					fail("invalid value for input '%s': %s", condition.Input, err)
+				}
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+
+	// Depth-first search for cycles, reported once at the first input of the cycle
+	const (
+		visiting = 1
+		done     = 2
+	)
+	state := map[string]int{}
+	var path []string
+	var visit func(name string) []string
+	visit = func(name string) []string {
+		switch state[name] {
+		case visiting:
+			return append(path[slices.Index(path, name):], name)
+		case done:
+			return nil
+		}
+		state[name] = visiting
+		path = append(path, name)
+		for _, condition := range byName[name].When {
+			if cycle := visit(condition.Input); cycle != nil {
+				return cycle
+			}
+		}
+		path = path[:len(path)-1]
+		state[name] = done
+		return nil
+	}
+	for _, input := range inputs {
+		if cycle := visit(input.Name); cycle != nil {
+			first := byName[cycle[0]]
+			return InputErrors{{Line: first.Line, Input: first.Name, Message: "when: circular reference " + strings.Join(cycle, " -> ")}}
+		}
+	}
+	return nil
+}
+
+// conditionValue converts a value of a condition on the input to the type of the input. A condition
+// on a multichoice input names one of its options.
+func (input *ApprovalInput) conditionValue(value string) (interface{}, error) {
+	if input.Type == InputTypeMultiChoice {
+		parsed, err := input.parseSelection(value)
+		if err != nil {
+			return nil, err
+		}
+		if len(parsed) != 1 {
+			return nil, fmt.Errorf("expected one of the options: %s", strings.Join(input.Options, ", "))
+		}
+		return parsed[0], nil
+	}
+	return input.parseType(value)
+}
+
+// matches reports whether the value of the referenced input satisfies the condition
+func (condition InputCondition) matches(input *ApprovalInput, value interface{}) bool {
+	if value == nil {
+		return false
+	}
+	for _, expected := range condition.Values {
+		want, err := input.conditionValue(expected)
+		if err != nil {
+			continue
+		}
+		if input.Type == InputTypeMultiChoice {
+			if selected, ok := value.([]interface{}); ok && slices.Contains(selected, want) {
+				return true
+			}
+			continue
+		}
+		if equalValues(want, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// equalValues compares two input values, numbers by their value rather than their form
+func equalValues(a interface{}, b interface{}) bool {
+	x, xNumber := a.(json.Number)
+	y, yNumber := b.(json.Number)
+	if xNumber && yNumber {
+		return compareNumbers(x, y) == 0
+	}
+	as, errA := valueToString(a)
+	bs, errB := valueToString(b)
+	return errA == nil && errB == nil && as == bs
+}
+
+// applicableInputs returns the names of the inputs whose conditions are met by the values. An input
+// that does not apply counts as having no value in the conditions of other inputs.
+func applicableInputs(schema []ApprovalInput, values map[string]interface{}) map[string]bool {
+	byName := map[string]*ApprovalInput{}
+	for i := range schema {
+		byName[schema[i].Name] = &schema[i]
+	}
+
+	result := map[string]bool{}
+	var applies func(name string) bool
+	applies = func(name string) bool {
+		if applicable, ok := result[name]; ok {
+			return applicable
+		}
+		applicable := true
+		for _, condition := range byName[name].When {
+			referenced := byName[condition.Input]
+			if referenced == nil || !applies(condition.Input) || !condition.matches(referenced, values[condition.Input]) {
+				applicable = false
+				break
+			}
+		}
+		result[name] = applicable
+		return applicable
+	}
+	for _, input := range schema {
+		applies(input.Name)
+	}
+	return result
+}
+
+// conditionOrder orders the inputs so that every input follows the inputs its conditions refer to,
+// otherwise keeping the order of the definition
+func conditionOrder(schema []ApprovalInput) []ApprovalInput {
+	byName := map[string]*ApprovalInput{}
+	for i := range schema {
+		byName[schema[i].Name] = &schema[i]
+	}
+
+	var ordered []ApprovalInput
+	added := map[string]bool{}
+	var add func(input *ApprovalInput)
+	add = func(input *ApprovalInput) {
+		if input == nil || added[input.Name] {
+			return
+		}
+		added[input.Name] = true
+		for _, condition := range input.When {
+			add(byName[condition.Input])
+		}
+		ordered = append(ordered, *input)
+	}
+	for i := range schema {
+		add(&schema[i])
+	}
+	return ordered
+}
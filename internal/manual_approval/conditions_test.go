@@ -0,0 +1,217 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const rollbackInputs = `action:
+  type: choice
+  options: [deploy, rollback]
+  required: true
+reason:
+  type: text
+  required: true
+  when: {action: rollback}
+ticket:
+  type: string
+  required: true
+  when:
+    action: rollback
+    reason: [incident, outage]
+regions:
+  type: multichoice
+  options: [eu, us]
+canary:
+  type: integer
+  when: {regions: us}`
+
+func Test_parseWhen(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		when       map[string][]InputCondition
+		err        string
+	}{
+		{
+			name:       "conditions",
+			definition: rollbackInputs,
+			when: map[string][]InputCondition{
+				"reason": {{Input: "action", Values: []string{"rollback"}, Line: 8}},
+				"ticket": {
+					{Input: "action", Values: []string{"rollback"}, Line: 13},
+					{Input: "reason", Values: []string{"incident", "outage"}, Line: 14},
+				},
+				"canary": {{Input: "regions", Values: []string{"us"}, Line: 20}},
+			},
+		},
+		{
+			name:       "not a mapping",
+			definition: "a:\n  type: string\n  when: [b]",
+			err:        "invalid approvalInputs: line 3: input 'a': when must be a mapping of input names to values",
+		},
+		{
+			name:       "nested value",
+			definition: "a:\n  type: string\n  when:\n    b: {c: d}\nb:\n  type: string",
+			err:        "invalid approvalInputs: line 4: input 'a': when value of 'b' must be a value or a list of values",
+		},
+		{
+			name:       "unknown input",
+			definition: "a:\n  type: string\n  when: {missing: x}",
+			err:        "invalid approvalInputs: line 3: input 'a': when: unknown input 'missing'",
+		},
+		{
+			name:       "refers to itself",
+			definition: "a:\n  type: string\n  when: {a: x}",
+			err:        "invalid approvalInputs: line 3: input 'a': when: input refers to itself",
+		},
+		{
+			name:       "value the input can never have",
+			definition: "a:\n  type: choice\n  options: [x, y]\nb:\n  type: string\n  when: {a: z}\nc:\n  type: boolean\nd:\n  type: string\n  when: {c: maybe}",
+			err: "invalid approvalInputs: line 6: input 'b': when: invalid value for input 'a': 'z' is not one of the options: x, y; " +
+				"line 11: input 'd': when: invalid value for input 'c': 'maybe' is not a boolean",
+		},
+		{
+			name:       "circular reference",
+			definition: "a:\n  type: string\n  when: {b: x}\nb:\n  type: string\n  when: {c: y}\nc:\n  type: string\n  when: {a: z}",
+			err:        "invalid approvalInputs: line 1: input 'a': when: circular reference a -> b -> c -> a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			inputs, err := parseApprovalInputs(tt.definition)
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			when := map[string][]InputCondition{}
+			for _, input := range inputs {
+				if input.When != nil {
+					when[input.Name] = input.When
+				}
+			}
+			require.Equal(t, tt.when, when)
+		})
+	}
+}
+
+func Test_inputsFromValuesWithConditions(t *testing.T) {
+	schema, err := parseApprovalInputs(rollbackInputs)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name   string
+		values map[string]string
+		inputs []interface{}
+		err    string
+	}{
+		{
+			name:   "conditions not met",
+			values: map[string]string{"action": "deploy", "reason": "left out", "regions": "eu", "canary": "5"},
+			inputs: []interface{}{
+				map[string]interface{}{"name": "action", "value": "deploy", "is_default": false},
+				map[string]interface{}{"name": "regions", "value": []interface{}{"eu"}, "is_default": false},
+			},
+		},
+		{
+			name:   "required when met",
+			values: map[string]string{"action": "rollback"},
+			err:    "input 'reason' is required",
+		},
+		{
+			name:   "chained conditions",
+			values: map[string]string{"action": "rollback", "reason": "outage", "ticket": "INC1", "regions": "eu,us", "canary": "5"},
+			inputs: []interface{}{
+				map[string]interface{}{"name": "action", "value": "rollback", "is_default": false},
+				map[string]interface{}{"name": "reason", "value": "outage", "is_default": false},
+				map[string]interface{}{"name": "ticket", "value": "INC1", "is_default": false},
+				map[string]interface{}{"name": "regions", "value": []interface{}{"eu", "us"}, "is_default": false},
+				map[string]interface{}{"name": "canary", "value": json.Number("5"), "is_default": false},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			inputs, err := inputsFromValues(schema, tt.values, true)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.inputs, inputs)
+		})
+	}
+}
+
+func Test_callbackWithConditionalInputs(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  string
+		inputs  string
+		outputs string
+		err     string
+	}{
+		{
+			name:    "inapplicable inputs are left out",
+			status:  "APPROVED",
+			inputs:  `[{"name":"action","value":"deploy"},{"name":"reason","value":""},{"name":"regions","value":["eu"]},{"name":"canary","value":3}]`,
+			outputs: `{"action":"deploy","regions":["eu"]}`,
+		},
+		{
+			name:    "applicable inputs are kept",
+			status:  "APPROVED",
+			inputs:  `[{"name":"action","value":"rollback"},{"name":"reason","value":"incident"},{"name":"ticket","value":"INC1"}]`,
+			outputs: `{"action":"rollback","reason":"incident","ticket":"INC1"}`,
+		},
+		{
+			name:   "conditionally required input missing",
+			status: "APPROVED",
+			inputs: `[{"name":"action","value":"rollback"},{"name":"reason","value":"outage"}]`,
+			err:    "input 'ticket' is required",
+		},
+		{
+			name:    "required inputs are not needed to reject",
+			status:  "REJECTED",
+			inputs:  `[]`,
+			outputs: `{}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			c := Config{
+				Handler:    "callback",
+				Inputs:     rollbackInputs,
+				OutputsDir: dir,
+				StatusFile: filepath.Join(dir, "status"),
+				Backend:    &fakeBackend{},
+				Payload:    fmt.Sprintf(`{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_%s","comments":"","respondedOn":"2024-05-01T12:00:00Z","userName":"jane","inputs":%s}`, tt.status, tt.inputs),
+				Output:     &MockStdOut{MockPrintf: func(string, ...any) {}},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				requireStatusFile(t, fmt.Sprintf(`{"message":"Invalid approval input values: '%s'","status":"FAILED"}`, tt.err), c.StatusFile)
+				return
+			}
+			require.NoError(t, err)
+			out, err := os.ReadFile(filepath.Join(dir, "approvalInputValues"))
+			require.NoError(t, err)
+			require.Equal(t, tt.outputs, string(out))
+		})
+	}
+}
@@ -0,0 +1,226 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// delegationDateLayout is the layout of the dates of a delegation period
+const delegationDateLayout = "2006-01-02"
+
+// delegationPattern matches "<approver> -> <substitute> [from <date>] [to <date>]"
+var delegationPattern = regexp.MustCompile(`^(\S+)\s*->\s*(\S+)(?:\s+from\s+(\S+))?(?:\s+to\s+(\S+))?$`)
+
+// delegation hands the approvals of an approver to a substitute. From and To are the first and the
+// last day of the delegation, the delegation has no start or no end when they are empty.
+type delegation struct {
+	Approver string `json:"approver"`
+	Delegate string `json:"delegate"`
+	From     string `json:"from,omitempty"`
+	To       string `json:"to,omitempty"`
+
+	// from and to are the start of the first day and of the day after the last day
+	from time.Time
+	to   time.Time
+	line int
+}
+
+// DelegationError is a problem in a delegation definition, Line is the line within the definition
+type DelegationError struct {
+	Line    int
+	Message string
+}
+
+func (e *DelegationError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+}
+
+// delegations reads the inline delegations and the delegations file, falling back to the DELEGATIONS
+// and DELEGATIONS_FILE environment variables
+func (k *Config) delegations() ([]delegation, bool, error) {
+	inline := valueOrEnv(k.Delegations, "DELEGATIONS")
+	file := valueOrEnv(k.DelegationsFile, "DELEGATIONS_FILE")
+	if inline == "" && file == "" {
+		return nil, false, nil
+	}
+
+	delegations, err := parseDelegations(inline)
+	if err != nil {
+		return nil, true, configErrorf("invalid DELEGATIONS: %w", err)
+	}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, true, configErrorf("failed to read DELEGATIONS_FILE: %w", err)
+		}
+		fromFile, err := parseDelegations(string(content))
+		if err != nil {
+			return nil, true, configErrorf("invalid DELEGATIONS_FILE %s: %w", file, err)
+		}
+		for _, d := range fromFile {
+			if other := overlapping(delegations, d); other != nil {
+				return nil, true, configErrorf("delegation of '%s' in DELEGATIONS_FILE %s line %d overlaps DELEGATIONS line %d", d.Approver, file, d.line, other.line)
+			}
+		}
+		delegations = append(delegations, fromFile...)
+	}
+	return delegations, true, nil
+}
+
+// parseDelegations parses one delegation per line, empty lines and lines starting with # are skipped
+func parseDelegations(source string) ([]delegation, error) {
+	var delegations []delegation
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		match := delegationPattern.FindStringSubmatch(line)
+		if match == nil {
+			return nil, &DelegationError{Line: i + 1, Message: fmt.Sprintf("invalid delegation '%s', expected '<approver> -> <substitute> [from <yyyy-mm-dd>] [to <yyyy-mm-dd>]'", line)}
+		}
+		d := delegation{Approver: match[1], Delegate: match[2], From: match[3], To: match[4], line: i + 1}
+		if strings.EqualFold(d.Approver, d.Delegate) {
+			return nil, &DelegationError{Line: d.line, Message: fmt.Sprintf("'%s' cannot be delegated to itself", d.Approver)}
+		}
+		if d.From != "" {
+			from, err := time.Parse(delegationDateLayout, d.From)
+			if err != nil {
+				return nil, &DelegationError{Line: d.line, Message: fmt.Sprintf("invalid from date '%s', expected yyyy-mm-dd", d.From)}
+			}
+			d.from = from
+		}
+		if d.To != "" {
+			to, err := time.Parse(delegationDateLayout, d.To)
+			if err != nil {
+				return nil, &DelegationError{Line: d.line, Message: fmt.Sprintf("invalid to date '%s', expected yyyy-mm-dd", d.To)}
+			}
+			d.to = to.AddDate(0, 0, 1)
+		}
+		if !d.from.IsZero() && !d.to.IsZero() && !d.from.Before(d.to) {
+			return nil, &DelegationError{Line: d.line, Message: fmt.Sprintf("to date %s is before from date %s", d.To, d.From)}
+		}
+		if other := overlapping(delegations, d); other != nil {
+			return nil, &DelegationError{Line: d.line, Message: fmt.Sprintf("delegation of '%s' overlaps line %d", d.Approver, other.line)}
+		}
+		delegations = append(delegations, d)
+	}
+	return delegations, nil
+}
+
+// overlapping returns the delegation of the same approver whose period overlaps the period of d
+func overlapping(delegations []delegation, d delegation) *delegation {
+	for i, other := range delegations {
+		if !strings.EqualFold(other.Approver, d.Approver) {
+			continue
+		}
+		if (other.to.IsZero() || d.from.Before(other.to)) && (d.to.IsZero() || other.from.Before(d.to)) {
+			return &delegations[i]
+		}
+	}
+	return nil
+}
+
+// active reports whether the delegation applies at the given time
+func (d delegation) active(now time.Time) bool {
+	return !now.Before(d.from) && (d.to.IsZero() || now.Before(d.to))
+}
+
+// period describes when the delegation applies
+func (d delegation) period() string {
+	switch {
+	case d.From != "" && d.To != "":
+		return fmt.Sprintf(" from %s to %s", d.From, d.To)
+	case d.From != "":
+		return fmt.Sprintf(" from %s", d.From)
+	case d.To != "":
+		return fmt.Sprintf(" until %s", d.To)
+	}
+	return ""
+}
+
+// applyDelegations replaces the approvers with their substitutes while the delegations are active.
+// Substitutes who delegate themselves are replaced in turn. Approvers and applied delegations are
+// listed once.
+func applyDelegations(approvers []string, delegations []delegation, now time.Time) ([]string, []delegation, error) {
+	var result []string
+	var applied []delegation
+	for _, approver := range approvers {
+		current := strings.TrimSpace(approver)
+		chain := []string{current}
+		for {
+			d := activeDelegation(delegations, current, now)
+			if d == nil {
+				break
+			}
+			if !slices.Contains(applied, *d) {
+				applied = append(applied, *d)
+			}
+			current = d.Delegate
+			for _, previous := range chain {
+				if strings.EqualFold(previous, current) {
+					return nil, nil, configErrorf("circular delegation: %s -> %s", strings.Join(chain, " -> "), current)
+				}
+			}
+			chain = append(chain, current)
+		}
+
+		if !containsFold(result, current) {
+			result = append(result, current)
+		}
+	}
+	return result, applied, nil
+}
+
+func activeDelegation(delegations []delegation, approver string, now time.Time) *delegation {
+	for i, d := range delegations {
+		if strings.EqualFold(d.Approver, approver) && d.active(now) {
+			return &delegations[i]
+		}
+	}
+	return nil
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// delegateApprovers applies the configured delegations to the approvers, logs them and writes them
+// to the delegations output
+func (k *Config) delegateApprovers(approvers []string) ([]string, error) {
+	delegations, configured, err := k.delegations()
+	if err != nil || !configured {
+		return approvers, err
+	}
+
+	delegated, applied, err := applyDelegations(approvers, delegations, k.now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	for _, d := range applied {
+		k.Output.Printf("Approver %s is delegated to %s%s\n", d.Approver, d.Delegate, d.period())
+	}
+
+	if applied == nil {
+		applied = []delegation{}
+	}
+	out, err := json.Marshal(applied)
+	if err != nil {
+		return nil, err
+	}
+	if err := k.writeAsOutput("delegations", out); err != nil {
+		return nil, err
+	}
+	return delegated, nil
+}
@@ -0,0 +1,207 @@
+package manual_approval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDelegations(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		err    string
+	}{
+		{
+			name:   "valid",
+			source: "# holidays\nalice -> bob from 2026-12-20 to 2027-01-05\n\nalice@mail.com->carol@mail.com from 2027-01-06\ndave -> erin to 2026-11-01\nfrank -> grace",
+		},
+		{
+			name:   "invalid syntax",
+			source: "alice -> bob\nalice => carol",
+			err:    "line 2: invalid delegation 'alice => carol', expected '<approver> -> <substitute> [from <yyyy-mm-dd>] [to <yyyy-mm-dd>]'",
+		},
+		{
+			name:   "invalid date",
+			source: "alice -> bob from 20.12.2026",
+			err:    "line 1: invalid from date '20.12.2026', expected yyyy-mm-dd",
+		},
+		{
+			name:   "reversed period",
+			source: "alice -> bob from 2027-01-05 to 2026-12-20",
+			err:    "line 1: to date 2026-12-20 is before from date 2027-01-05",
+		},
+		{
+			name:   "self delegation",
+			source: "Alice -> alice",
+			err:    "line 1: 'Alice' cannot be delegated to itself",
+		},
+		{
+			name:   "overlapping periods",
+			source: "alice -> bob from 2026-12-20 to 2027-01-05\nalice -> carol from 2027-01-05",
+			err:    "line 2: delegation of 'alice' overlaps line 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			_, err := parseDelegations(tt.source)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func Test_applyDelegations(t *testing.T) {
+	const delegations = "alice -> bob from 2026-12-20 to 2027-01-05\nbob -> carol from 2027-01-01 to 2027-01-02\ndave -> alice"
+
+	tests := []struct {
+		name      string
+		approvers []string
+		now       time.Time
+		approved  []string
+		applied   []string
+		err       string
+	}{
+		{
+			name:      "before the period",
+			approvers: []string{"alice", "erin"},
+			now:       time.Date(2026, 12, 19, 23, 59, 59, 0, time.UTC),
+			approved:  []string{"alice", "erin"},
+		},
+		{
+			name:      "first day",
+			approvers: []string{"alice", "erin"},
+			now:       time.Date(2026, 12, 20, 0, 0, 0, 0, time.UTC),
+			approved:  []string{"bob", "erin"},
+			applied:   []string{"alice -> bob"},
+		},
+		{
+			name:      "last day",
+			approvers: []string{"ALICE"},
+			now:       time.Date(2027, 1, 5, 23, 59, 59, 0, time.UTC),
+			approved:  []string{"bob"},
+			applied:   []string{"alice -> bob"},
+		},
+		{
+			name:      "after the period",
+			approvers: []string{"alice"},
+			now:       time.Date(2027, 1, 6, 0, 0, 0, 0, time.UTC),
+			approved:  []string{"alice"},
+		},
+		{
+			name:      "chained and duplicate substitutes",
+			approvers: []string{"dave", " alice", "carol"},
+			now:       time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC),
+			approved:  []string{"carol"},
+			applied:   []string{"dave -> alice", "alice -> bob", "bob -> carol"},
+		},
+		{
+			name:      "circular",
+			approvers: []string{"alice"},
+			now:       time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC),
+			err:       "circular delegation: alice -> bob -> carol -> alice",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			source := delegations
+			if tt.err != "" {
+				source += "\ncarol -> alice"
+			}
+			parsed, err := parseDelegations(source)
+			require.NoError(t, err)
+
+			// Run
+			approvers, applied, err := applyDelegations(tt.approvers, parsed, tt.now)
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.approved, approvers)
+			var appliedNames []string
+			for _, d := range applied {
+				appliedNames = append(appliedNames, d.Approver+" -> "+d.Delegate)
+			}
+			require.Equal(t, tt.applied, appliedNames)
+		})
+	}
+}
+
+func Test_initDelegations(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	delegationsFile := filepath.Join(dir, "delegations.txt")
+	require.NoError(t, os.WriteFile(delegationsFile, []byte("# out of office\nuser@mail.com -> deputy@mail.com from 2026-12-20 to 2027-01-05\n"), 0644))
+	backend := &fakeBackend{}
+	var testOutput strings.Builder
+	c := Config{
+		Handler:         "init",
+		Approvers:       "123,user@mail.com",
+		Delegations:     "123 -> 456",
+		DelegationsFile: delegationsFile,
+		Backend:         backend,
+		OutputsDir:      dir,
+		StatusFile:      filepath.Join(dir, "status"),
+		clock:           func() time.Time { return time.Date(2026, 12, 24, 9, 0, 0, 0, time.UTC) },
+		Output: &MockStdOut{
+			MockPrintf: func(format string, a ...any) {
+				testOutput.WriteString(fmt.Sprintf(format, a...))
+			},
+		},
+	}
+
+	// Run
+	err := c.init()
+
+	// Verify
+	require.NoError(t, err)
+	require.Len(t, backend.created, 1)
+	require.Equal(t, []string{"456", "deputy@mail.com"}, backend.created[0]["approvers"])
+	require.Equal(t, "Approver 123 is delegated to 456\n"+
+		"Approver user@mail.com is delegated to deputy@mail.com from 2026-12-20 to 2027-01-05\n"+
+		"Waiting for approval from one of the following: testUserName\n", testOutput.String())
+	out, err := os.ReadFile(filepath.Join(dir, "delegations"))
+	require.NoError(t, err)
+	require.JSONEq(t, `[{"approver":"123","delegate":"456"},{"approver":"user@mail.com","delegate":"deputy@mail.com","from":"2026-12-20","to":"2027-01-05"}]`, string(out))
+}
+
+func Test_initDelegationsInvalid(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	backend := &fakeBackend{}
+	c := Config{
+		Handler:     "init",
+		Approvers:   "123",
+		Delegations: "123 -> 456\n123 -> 789",
+		Backend:     backend,
+		OutputsDir:  dir,
+		StatusFile:  filepath.Join(dir, "status"),
+		Output: &MockStdOut{
+			MockPrintf: func(format string, a ...any) {},
+		},
+	}
+
+	// Run
+	err := c.init()
+
+	// Verify
+	require.EqualError(t, err, "invalid DELEGATIONS: line 2: delegation of '123' overlaps line 1")
+	require.Equal(t, ExitConfig, ExitCode(err))
+	require.Empty(t, backend.created)
+	requireStatusFile(t, `{"message":"Failed to initialize workflow manual approval request: 'invalid DELEGATIONS: line 2: delegation of '123' overlaps line 1'","status":"FAILED"}`, c.StatusFile)
+}
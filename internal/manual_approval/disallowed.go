@@ -0,0 +1,220 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// authorsOfPrefix marks a disallowedApprovers entry standing for the authors of a commit range
+const authorsOfPrefix = "authors-of:"
+
+// disallowedApprover is a user who may not approve and the reason why
+type disallowedApprover struct {
+	identity string
+	reason   string
+}
+
+// parseDisallowedApprovers splits the comma or newline separated entries into users and commit ranges
+func parseDisallowedApprovers(value string) ([]string, []string, error) {
+	var users, ranges []string
+	entries := strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' })
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if revisionRange, ok := strings.CutPrefix(entry, authorsOfPrefix); ok {
+			revisionRange = strings.TrimSpace(revisionRange)
+			if revisionRange == "" || strings.HasPrefix(revisionRange, "-") || strings.ContainsAny(revisionRange, " \t") {
+				return nil, nil, fmt.Errorf("invalid commit range '%s' in '%s'", revisionRange, entry)
+			}
+			ranges = append(ranges, revisionRange)
+			continue
+		}
+		users = append(users, entry)
+	}
+	return users, ranges, nil
+}
+
+// disallowedApprovers resolves the users who may not approve, falling back to the
+// DISALLOWED_APPROVERS environment variable. The authors of authors-of commit ranges are read from
+// the git checkout in the working directory, or from the authors the init handler resolved. The
+// approvers of earlier stages in distinctFrom are disallowed as well.
+func (k *Config) disallowedApprovers() ([]disallowedApprover, error) {
+	disallowed, err := k.earlierApprovers()
+	if err != nil {
+		return nil, err
+	}
+
+	value := valueOrEnv(k.DisallowedApprovers, "DISALLOWED_APPROVERS")
+	if value == "" {
+		return disallowed, nil
+	}
+	users, ranges, err := parseDisallowedApprovers(value)
+	if err != nil {
+		return nil, configErrorf("invalid DISALLOWED_APPROVERS: %w", err)
+	}
+
+	for _, user := range users {
+		disallowed = append(disallowed, disallowedApprover{identity: user, reason: "listed in disallowedApprovers"})
+	}
+	for _, revisionRange := range ranges {
+		authors, err := k.rangeAuthors(revisionRange)
+		if err != nil {
+			return nil, configErrorf("failed to resolve the authors of %s: %w", revisionRange, err)
+		}
+		for _, author := range authors {
+			disallowed = append(disallowed, disallowedApprover{identity: author, reason: fmt.Sprintf("author of a commit in %s", revisionRange)})
+		}
+	}
+	return disallowed, nil
+}
+
+// rangeAuthors returns the authors of a commit range. Git is run once per range, the callback
+// handler takes the authors resolved by the init handler instead: its image has neither git nor the
+// checkout.
+func (k *Config) rangeAuthors(revisionRange string) ([]string, error) {
+	if authors, ok := k.resolvedAuthors[revisionRange]; ok {
+		return authors, nil
+	}
+	if value := valueOrEnv(k.DisallowedAuthors, "DISALLOWED_AUTHORS"); value != "" {
+		resolved := map[string][]string{}
+		if err := json.Unmarshal([]byte(value), &resolved); err != nil {
+			return nil, fmt.Errorf("invalid DISALLOWED_AUTHORS: %w", err)
+		}
+		if authors, ok := resolved[revisionRange]; ok {
+			return authors, nil
+		}
+	}
+	if k.Handler == "callback" {
+		return nil, fmt.Errorf("the authors were not resolved when the approval was requested")
+	}
+
+	authors, err := k.commitAuthors(revisionRange)
+	if err != nil {
+		return nil, err
+	}
+	if k.resolvedAuthors == nil {
+		k.resolvedAuthors = map[string][]string{}
+	}
+	k.resolvedAuthors[revisionRange] = authors
+	return authors, nil
+}
+
+// resolveDisallowedAuthors resolves the authors of the authors-of commit ranges and writes them to
+// the disallowedAuthors output, for the callback handler to check the approver against
+func (k *Config) resolveDisallowedAuthors() error {
+	if _, err := k.disallowedApprovers(); err != nil || len(k.resolvedAuthors) == 0 {
+		return err
+	}
+	out, err := json.Marshal(k.resolvedAuthors)
+	if err != nil {
+		return err
+	}
+	return k.writeAsOutput("disallowedAuthors", out)
+}
+
+// commitAuthors returns the names and email addresses of the authors of the commits in the range
+func (k *Config) commitAuthors(revisionRange string) ([]string, error) {
+	out, err := k.gitOutput("log", "--format=%an%n%ae", revisionRange, "--")
+	if err != nil {
+		return nil, err
+	}
+
+	var authors []string
+	for _, line := range strings.Split(string(out), "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !containsFold(authors, line) {
+			authors = append(authors, line)
+		}
+	}
+	debugf("Authors of %s: '%s'\n", revisionRange, strings.Join(authors, ", "))
+	return authors, nil
+}
+
+// gitOutput runs git with the arguments and returns its output. A missing git command is reported
+// as such, authors-of cannot be resolved without it.
+func (k *Config) gitOutput(args ...string) ([]byte, error) {
+	git := k.git
+	if git == nil {
+		git = k.runGit
+	}
+	out, err := git(args...)
+	if errors.Is(err, exec.ErrNotFound) {
+		return nil, fmt.Errorf("git is not available, authors-of needs git and a checkout of the repository")
+	}
+	return out, err
+}
+
+// runGit runs git in the working directory and returns its standard output
+func (k *Config) runGit(args ...string) ([]byte, error) {
+	out, err := exec.CommandContext(k.ctx(), "git", args...).Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && len(exitErr.Stderr) > 0 {
+		return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(string(exitErr.Stderr)))
+	}
+	return out, err
+}
+
+// findDisallowed returns the entry matching one of the identities of a user
+func findDisallowed(disallowed []disallowedApprover, identities ...string) *disallowedApprover {
+	for i, d := range disallowed {
+		for _, identity := range identities {
+			if identity != "" && strings.EqualFold(strings.TrimSpace(identity), d.identity) {
+				return &disallowed[i]
+			}
+		}
+	}
+	return nil
+}
+
+// removeDisallowedApprovers leaves the disallowed users out of the approvers of a new request. It
+// fails when no approver is left, because the request would then be open to every eligible user.
+func (k *Config) removeDisallowedApprovers(approvers []string) ([]string, error) {
+	if len(approvers) == 0 {
+		return approvers, nil
+	}
+	disallowed, err := k.disallowedApprovers()
+	if err != nil || len(disallowed) == 0 {
+		return approvers, err
+	}
+
+	var allowed []string
+	for _, approver := range approvers {
+		if d := findDisallowed(disallowed, approver); d != nil {
+			k.Output.Printf("Approver %s is not allowed to approve (%s) and is left out\n", strings.TrimSpace(approver), d.reason)
+			continue
+		}
+		allowed = append(allowed, approver)
+	}
+	if len(allowed) == 0 {
+		return nil, configErrorf("none of the approvers is allowed to approve")
+	}
+	return allowed, nil
+}
+
+// checkApproverAllowed fails the job when a disallowed user approved. Rejections are accepted from
+// anyone.
+func (k *Config) checkApproverAllowed(approvalStatus string, identities ...string) error {
+	if approvalStatus != "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED" {
+		return nil
+	}
+
+	disallowed, err := k.disallowedApprovers()
+	if err == nil {
+		if d := findDisallowed(disallowed, identities...); d != nil {
+			err = validationErrorf("approver '%s' is not allowed to approve: %s", d.identity, d.reason)
+		}
+	}
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Invalid approval decision: '%s'", err), err)
+		if ferr != nil {
+			return ferr
+		}
+		return err
+	}
+	return nil
+}
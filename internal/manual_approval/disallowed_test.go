@@ -0,0 +1,228 @@
+package manual_approval
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseDisallowedApprovers(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		users  []string
+		ranges []string
+		err    string
+	}{
+		{
+			name:   "users and ranges",
+			value:  "123, jane@mail.com\nauthors-of: origin/main..HEAD,\nauthors-of:HEAD~3..HEAD",
+			users:  []string{"123", "jane@mail.com"},
+			ranges: []string{"origin/main..HEAD", "HEAD~3..HEAD"},
+		},
+		{
+			name:  "empty range",
+			value: "authors-of: ",
+			err:   "invalid commit range '' in 'authors-of:'",
+		},
+		{
+			name:  "option instead of range",
+			value: "authors-of: --output=/tmp/x",
+			err:   "invalid commit range '--output=/tmp/x' in 'authors-of: --output=/tmp/x'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			users, ranges, err := parseDisallowedApprovers(tt.value)
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.users, users)
+			require.Equal(t, tt.ranges, ranges)
+		})
+	}
+}
+
+// fakeGit returns the log of the commit range origin/main..HEAD
+func fakeGit(t *testing.T) func(args ...string) ([]byte, error) {
+	return func(args ...string) ([]byte, error) {
+		require.Equal(t, []string{"log", "--format=%an%n%ae"}, args[:2])
+		if args[2] != "origin/main..HEAD" {
+			return nil, fmt.Errorf("exit status 128: fatal: bad revision '%s'", args[2])
+		}
+		return []byte("Jane Doe\njane@mail.com\nJane Doe\njane@mail.com\nbot\nbot@mail.com\n"), nil
+	}
+}
+
+func Test_callbackDisallowedApprovers(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		ranges    string
+		status    string
+		decisions int
+		err       string
+	}{
+		{
+			name:      "approved by a commit author",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"JANE@mail.com","userId":"42"}`,
+			ranges:    "origin/main..HEAD",
+			status:    `{"message":"Invalid approval decision: 'approver 'jane@mail.com' is not allowed to approve: author of a commit in origin/main..HEAD'","status":"FAILED"}`,
+			decisions: 0,
+			err:       "approver 'jane@mail.com' is not allowed to approve: author of a commit in origin/main..HEAD",
+		},
+		{
+			name:      "approved by a listed user ID",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"Release Manager","userId":"123"}`,
+			ranges:    "origin/main..HEAD",
+			status:    `{"message":"Invalid approval decision: 'approver '123' is not allowed to approve: listed in disallowedApprovers'","status":"FAILED"}`,
+			decisions: 0,
+			err:       "approver '123' is not allowed to approve: listed in disallowedApprovers",
+		},
+		{
+			name:      "rejected by a commit author",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"Jane Doe"}`,
+			ranges:    "origin/main..HEAD",
+			status:    `{"message":"Successfully changed workflow manual approval status","status":"REJECTED"}`,
+			decisions: 1,
+		},
+		{
+			name:      "approved by someone else",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"joe"}`,
+			ranges:    "origin/main..HEAD",
+			status:    `{"message":"Successfully changed workflow manual approval status","status":"APPROVED"}`,
+			decisions: 1,
+		},
+		{
+			name:      "commit range not resolved by init fails closed",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"joe"}`,
+			ranges:    "v9..HEAD",
+			status:    `{"message":"Invalid approval decision: 'failed to resolve the authors of v9..HEAD: the authors were not resolved when the approval was requested'","status":"FAILED"}`,
+			decisions: 0,
+			err:       "failed to resolve the authors of v9..HEAD: the authors were not resolved when the approval was requested",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			c := Config{
+				Handler:             "callback",
+				Payload:             tt.payload,
+				DisallowedApprovers: "123\nauthors-of: " + tt.ranges,
+				DisallowedAuthors:   `{"origin/main..HEAD":["Jane Doe","jane@mail.com","bot","bot@mail.com"]}`,
+				Backend:             backend,
+				OutputsDir:          dir,
+				StatusFile:          filepath.Join(dir, "status"),
+				git: func(args ...string) ([]byte, error) {
+					t.Fatalf("unexpected git %v", args)
+					return nil, nil
+				},
+				Output: &MockStdOut{
+					MockPrintf:  func(format string, a ...any) {},
+					MockPrintln: func(a ...any) {},
+				},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+			require.Len(t, backend.decisions, tt.decisions)
+			requireStatusFile(t, tt.status, c.StatusFile)
+		})
+	}
+}
+
+func Test_initDisallowedApprovers(t *testing.T) {
+	tests := []struct {
+		name      string
+		approvers string
+		git       func(args ...string) ([]byte, error)
+		created   []string
+		output    string
+		err       string
+	}{
+		{
+			name:      "disallowed approvers are left out",
+			approvers: "jane@mail.com,456,bot",
+			created:   []string{"456"},
+			output: "Approver jane@mail.com is not allowed to approve (author of a commit in origin/main..HEAD) and is left out\n" +
+				"Approver bot is not allowed to approve (author of a commit in origin/main..HEAD) and is left out\n" +
+				"Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:      "no approver left",
+			approvers: "jane@mail.com,123",
+			output: "Approver jane@mail.com is not allowed to approve (author of a commit in origin/main..HEAD) and is left out\n" +
+				"Approver 123 is not allowed to approve (listed in disallowedApprovers) and is left out\n",
+			err: "none of the approvers is allowed to approve",
+		},
+		{
+			name:      "git not available",
+			approvers: "jane@mail.com,456",
+			git: func(args ...string) ([]byte, error) {
+				return nil, &exec.Error{Name: "git", Err: exec.ErrNotFound}
+			},
+			err: "failed to resolve the authors of origin/main..HEAD: git is not available, authors-of needs git and a checkout of the repository",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			var testOutput strings.Builder
+			c := Config{
+				Handler:             "init",
+				Approvers:           tt.approvers,
+				DisallowedApprovers: "123, authors-of: origin/main..HEAD",
+				Backend:             backend,
+				OutputsDir:          dir,
+				StatusFile:          filepath.Join(dir, "status"),
+				git:                 fakeGit(t),
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						testOutput.WriteString(fmt.Sprintf(format, a...))
+					},
+				},
+			}
+
+			if tt.git != nil {
+				c.git = tt.git
+			}
+
+			// Run
+			err := c.init()
+
+			// Verify
+			require.Equal(t, tt.output, testOutput.String())
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Empty(t, backend.created)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.created, backend.created[0]["approvers"])
+			authors, err := os.ReadFile(filepath.Join(dir, "disallowedAuthors"))
+			require.NoError(t, err)
+			require.Equal(t, `{"origin/main..HEAD":["Jane Doe","jane@mail.com","bot","bot@mail.com"]}`, string(authors))
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package manual_approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DecisionRecordSchemaVersion is the version of the decisionRecord output
+const DecisionRecordSchemaVersion = 1
+
+// DecisionRecord is written to the decisionRecord output once the approval is decided. Later approval
+// jobs of the workflow pass it to distinctFrom so the same person cannot approve both.
+type DecisionRecord struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	ApprovalID    string `json:"approvalId,omitempty"`
+	Decision      string `json:"decision"`
+	Approver      string `json:"approver"`
+	ApproverID    string `json:"approverId,omitempty"`
+	RespondedOn   string `json:"respondedOn,omitempty"`
+	ApprovalKey   string `json:"approvalKey,omitempty"`
+
+	// SavedOn is when the approval was saved to the approval store, it is only set there
+	SavedOn string `json:"savedOn,omitempty"`
+}
+
+// decisionRecord describes the decision of this job
+func (k *Config) decisionRecord() DecisionRecord {
+	details := k.statusDetails
+	return DecisionRecord{
+		SchemaVersion: DecisionRecordSchemaVersion,
+		ApprovalID:    details.approvalID,
+		Decision:      details.decision,
+		Approver:      details.approver,
+		ApproverID:    details.approverID,
+		RespondedOn:   details.respondedOn,
+		ApprovalKey:   strings.TrimSpace(valueOrEnv(k.ApprovalKey, "APPROVAL_KEY")),
+	}
+}
+
+// writeDecisionRecord writes the decision of this job to the decisionRecord output and saves
+// approvals to the approval store
+func (k *Config) writeDecisionRecord() error {
+	record := k.decisionRecord()
+	out, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	if err := k.writeAsOutput("decisionRecord", out); err != nil {
+		return err
+	}
+	k.saveApproval(record)
+	return nil
+}
+
+// parseDecisionRecords parses a JSON list of decision records or records following each other, such
+// as one per line. Empty values, as left by an expression of a skipped job, are ignored.
+func parseDecisionRecords(value string) ([]DecisionRecord, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return nil, nil
+	}
+
+	var records []DecisionRecord
+	if strings.HasPrefix(value, "[") {
+		if err := json.Unmarshal([]byte(value), &records); err != nil {
+			return nil, err
+		}
+	} else {
+		decoder := json.NewDecoder(bytes.NewReader([]byte(value)))
+		for {
+			var record DecisionRecord
+			err := decoder.Decode(&record)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			records = append(records, record)
+		}
+	}
+
+	for i, record := range records {
+		switch {
+		case record.SchemaVersion != DecisionRecordSchemaVersion:
+			return nil, fmt.Errorf("record %d: unsupported schemaVersion %d, expected %d", i+1, record.SchemaVersion, DecisionRecordSchemaVersion)
+		case record.Decision == "":
+			return nil, fmt.Errorf("record %d: decision is missing", i+1)
+		case record.Approver == "" && record.ApproverID == "":
+			return nil, fmt.Errorf("record %d: approver is missing", i+1)
+		}
+	}
+	return records, nil
+}
+
+// earlierApprovers returns the approvers of the decision records in distinctFrom, falling back to the
+// DISTINCT_FROM environment variable. Rejections are left out, the workflow does not go on after them.
+func (k *Config) earlierApprovers() ([]disallowedApprover, error) {
+	records, err := parseDecisionRecords(valueOrEnv(k.DistinctFrom, "DISTINCT_FROM"))
+	if err != nil {
+		return nil, configErrorf("invalid DISTINCT_FROM: %w", err)
+	}
+
+	var approvers []disallowedApprover
+	for _, record := range records {
+		if record.Decision != "APPROVED" {
+			continue
+		}
+		reason := "approved an earlier stage"
+		if record.ApprovalID != "" {
+			reason = fmt.Sprintf("approved the earlier approval request %s", record.ApprovalID)
+		}
+		for _, identity := range []string{record.Approver, record.ApproverID} {
+			if identity != "" {
+				approvers = append(approvers, disallowedApprover{identity: identity, reason: reason})
+			}
+		}
+	}
+	return approvers, nil
+}
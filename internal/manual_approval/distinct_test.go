@@ -0,0 +1,208 @@
+package manual_approval
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const stagingRecord = `{"schemaVersion":1,"approvalId":"a-0","decision":"APPROVED","approver":"jane@mail.com","approverId":"42","respondedOn":"2026-10-18T10:00:00Z"}`
+
+func Test_parseDecisionRecords(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		records []DecisionRecord
+		err     string
+	}{
+		{
+			name:  "one per line",
+			value: stagingRecord + "\n" + `{"schemaVersion":1,"decision":"REJECTED","approver":"joe"}` + "\n",
+			records: []DecisionRecord{
+				{SchemaVersion: 1, ApprovalID: "a-0", Decision: "APPROVED", Approver: "jane@mail.com", ApproverID: "42", RespondedOn: "2026-10-18T10:00:00Z"},
+				{SchemaVersion: 1, Decision: "REJECTED", Approver: "joe"},
+			},
+		},
+		{
+			name:  "JSON list",
+			value: `[{"schemaVersion":1,"decision":"APPROVED","approver":"joe"}]`,
+			records: []DecisionRecord{
+				{SchemaVersion: 1, Decision: "APPROVED", Approver: "joe"},
+			},
+		},
+		{
+			name:  "skipped job",
+			value: " \n",
+		},
+		{
+			name:  "unsupported schema version",
+			value: `{"schemaVersion":2,"decision":"APPROVED","approver":"joe"}`,
+			err:   "record 1: unsupported schemaVersion 2, expected 1",
+		},
+		{
+			name:  "missing approver",
+			value: stagingRecord + `{"schemaVersion":1,"decision":"APPROVED"}`,
+			err:   "record 2: approver is missing",
+		},
+		{
+			name:  "not JSON",
+			value: "jane@mail.com",
+			err:   "invalid character 'j' looking for beginning of value",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			records, err := parseDecisionRecords(tt.value)
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.records, records)
+		})
+	}
+}
+
+func Test_callbackDistinctFrom(t *testing.T) {
+	tests := []struct {
+		name      string
+		payload   string
+		status    string
+		record    string
+		decisions int
+		err       string
+	}{
+		{
+			name:      "approved by the staging approver",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"Jane Doe","userId":"42"}`,
+			status:    `{"message":"Invalid approval decision: 'approver '42' is not allowed to approve: approved the earlier approval request a-0'","status":"FAILED"}`,
+			decisions: 0,
+			err:       "approver '42' is not allowed to approve: approved the earlier approval request a-0",
+		},
+		{
+			name:      "rejected by the staging approver",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"jane@mail.com","userId":"42"}`,
+			status:    `{"message":"Successfully changed workflow manual approval status","status":"REJECTED"}`,
+			record:    `{"schemaVersion":1,"approvalId":"a-1","decision":"REJECTED","approver":"jane@mail.com","approverId":"42","respondedOn":"2026-10-18T12:30:00Z"}`,
+			decisions: 1,
+		},
+		{
+			name:      "approved by someone else",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"joe","userId":"7"}`,
+			status:    `{"message":"Successfully changed workflow manual approval status","status":"APPROVED"}`,
+			record:    `{"schemaVersion":1,"approvalId":"a-1","decision":"APPROVED","approver":"joe","approverId":"7","respondedOn":"2026-10-18T12:30:00Z"}`,
+			decisions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			c := Config{
+				Handler:      "callback",
+				Payload:      tt.payload,
+				DistinctFrom: stagingRecord,
+				Backend:      backend,
+				OutputsDir:   dir,
+				StatusFile:   filepath.Join(dir, "status"),
+				Output: &MockStdOut{
+					MockPrintf:  func(format string, a ...any) {},
+					MockPrintln: func(a ...any) {},
+				},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+			require.Len(t, backend.decisions, tt.decisions)
+			requireStatusFile(t, tt.status, c.StatusFile)
+			record, err := os.ReadFile(filepath.Join(dir, "decisionRecord"))
+			if tt.record == "" {
+				require.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+			require.NoError(t, err)
+			require.JSONEq(t, tt.record, string(record))
+		})
+	}
+}
+
+func Test_initDistinctFrom(t *testing.T) {
+	tests := []struct {
+		name         string
+		approvers    string
+		distinctFrom string
+		created      []string
+		output       string
+		err          string
+	}{
+		{
+			name:         "staging approver is left out",
+			approvers:    "jane@mail.com,joe@mail.com",
+			distinctFrom: stagingRecord,
+			created:      []string{"joe@mail.com"},
+			output: "Approver jane@mail.com is not allowed to approve (approved the earlier approval request a-0) and is left out\n" +
+				"Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:         "earlier rejection excludes nobody",
+			approvers:    "jane@mail.com",
+			distinctFrom: `[{"schemaVersion":1,"approvalId":"a-0","decision":"REJECTED","approver":"jane@mail.com"}]`,
+			created:      []string{"jane@mail.com"},
+			output:       "Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:         "invalid record",
+			approvers:    "jane@mail.com",
+			distinctFrom: `{"decision":"APPROVED"}`,
+			err:          "invalid DISTINCT_FROM: record 1: unsupported schemaVersion 0, expected 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			var testOutput strings.Builder
+			c := Config{
+				Handler:      "init",
+				Approvers:    tt.approvers,
+				DistinctFrom: tt.distinctFrom,
+				Backend:      backend,
+				StatusFile:   filepath.Join(dir, "status"),
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						testOutput.WriteString(fmt.Sprintf(format, a...))
+					},
+				},
+			}
+
+			// Run
+			err := c.init()
+
+			// Verify
+			require.Equal(t, tt.output, testOutput.String())
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Empty(t, backend.created)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.created, backend.created[0]["approvers"])
+		})
+	}
+}
@@ -0,0 +1,187 @@
+package manual_approval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// dryRunApprovalID is the ID of the approval request a dry run pretends to create
+const dryRunApprovalID = "dry-run"
+
+// dryRunApprover is the approver of the approval requests a dry run reads
+const dryRunApprover = "dry-run"
+
+// startDryRun sends the outputs and the status of the dry run to a new temporary directory
+func (k *Config) startDryRun() error {
+	dir, err := os.MkdirTemp("", "manual-approval-dry-run-")
+	if err != nil {
+		return err
+	}
+	k.OutputsDir = dir
+	k.StatusFile = filepath.Join(dir, "status")
+	k.Output.Printf("DRY RUN: platform API requests are logged instead of sent, outputs and status are written to %s\n", dir)
+	return nil
+}
+
+// dryRun logs the request that would be sent to the platform API and returns a synthetic response
+func (k *Config) dryRun(ctx context.Context, method string, apiPath string, query url.Values, body []byte) (string, error) {
+	apiUrl := valueOrEnv(k.URL, "URL")
+	if apiUrl == "" {
+		return "", configErrorf("--url flag or URL environment variable missing")
+	}
+	requestURL, err := url.JoinPath(apiUrl, apiPath)
+	if err != nil {
+		return "", err
+	}
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+
+	req, err := newRequest(ctx, method, requestURL, "", body)
+	if err != nil {
+		return "", err
+	}
+	k.logDryRunRequest(req, body)
+
+	return k.dryRunResponse(method, apiPath, body)
+}
+
+// logDryRunRequest logs a request that is not sent in a dry run, with its authorization redacted
+func (k *Config) logDryRunRequest(req *http.Request, body []byte) {
+	if req.Header.Get("Authorization") != "" {
+		req.Header.Set("Authorization", "Bearer <redacted>")
+	}
+
+	requestURL := req.URL.String()
+	k.dryRunRequests = append(k.dryRunRequests, req.Method+" "+requestURL)
+	k.Output.Printf("DRY RUN: %s %s\n", req.Method, requestURL)
+	for _, name := range slices.Sorted(maps.Keys(req.Header)) {
+		k.Output.Printf("  %s: %s\n", name, strings.Join(req.Header.Values(name), ", "))
+	}
+	if body != nil {
+		var indented bytes.Buffer
+		if err := json.Indent(&indented, body, "  ", "  "); err != nil {
+			indented.Reset()
+			indented.Write(body)
+		}
+		k.Output.Printf("  %s\n", indented.String())
+	}
+}
+
+// dryRunResponse is the response the platform API is assumed to send: a created approval request
+// waiting for the requested approvers and an approval request that is still waiting for a response.
+// The wait handler reads the approval request as approved with the default input values, so the
+// whole flow can be followed.
+func (k *Config) dryRunResponse(method string, apiPath string, body []byte) (string, error) {
+	var response interface{} = map[string]interface{}{}
+	switch {
+	case method == "POST" && apiPath == "/v1/workflows/approval":
+		request := struct {
+			Approvers []string `json:"approvers"`
+		}{}
+		if err := json.Unmarshal(body, &request); err != nil {
+			return "", err
+		}
+		approvers := []Approvers{}
+		for _, approver := range request.Approvers {
+			approvers = append(approvers, Approvers{UserName: approver})
+		}
+		response = &CreateManualApprovalResponse{ID: dryRunApprovalID, Approvers: approvers}
+	case method == "GET" && apiPath == "/v1/workflows/approvals":
+		response = &ListManualApprovalResponse{Approvals: []ApprovalRequest{}}
+	case method == "GET" && strings.HasPrefix(apiPath, "/v1/workflows/approval/"):
+		approval := &ApprovalRequest{
+			ID:             strings.TrimPrefix(apiPath, "/v1/workflows/approval/"),
+			Status:         ApprovalStatusPending,
+			ApprovalInputs: valueOrEnv(k.Inputs, "INPUTS"),
+		}
+		k.dryRunDecision(approval)
+		response = approval
+	}
+
+	out, err := json.Marshal(response)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
+
+// dryRunDecision approves the approval request the wait handler reads in a dry run, with the default
+// input values
+func (k *Config) dryRunDecision(approval *ApprovalRequest) {
+	if k.Handler != "wait" {
+		return
+	}
+	approval.Status = ApprovalStatusApproved
+	approval.UserName = dryRunApprover
+	approval.RespondedOn = k.now().UTC().Format(time.RFC3339)
+	if schema, err := usableApprovalInputs(approval.ApprovalInputs); err == nil {
+		approval.Inputs, _ = inputsFromValues(schema, map[string]string{}, true)
+	}
+}
+
+// logDryRunFile logs a file the file backend does not write in a dry run
+func (k *Config) logDryRunFile(path string, data []byte) {
+	k.dryRunFiles = append(k.dryRunFiles, path)
+	k.Output.Printf("DRY RUN: write %s\n", path)
+	k.Output.Printf("  %s\n", strings.ReplaceAll(string(data), "\n", "\n  "))
+}
+
+// printDryRunSummary shows the requests, the status and the outputs of the dry run
+func (k *Config) printDryRunSummary(err error) {
+	k.Output.Printf("\nDry run summary:\n")
+	k.Output.Printf("  Handler: %s\n", k.Handler)
+
+	if len(k.dryRunRequests) == 0 {
+		k.Output.Printf("  No platform API requests would have been sent\n")
+	} else {
+		k.Output.Printf("  Platform API requests that would have been sent:\n")
+		for _, request := range k.dryRunRequests {
+			k.Output.Printf("    %s\n", request)
+		}
+	}
+
+	if len(k.dryRunFiles) > 0 {
+		k.Output.Printf("  Files that would have been written:\n")
+		for _, file := range k.dryRunFiles {
+			k.Output.Printf("    %s\n", file)
+		}
+	}
+
+	if data, readErr := os.ReadFile(k.StatusFile); readErr == nil {
+		status := StatusDocument{}
+		if json.Unmarshal(data, &status) == nil {
+			k.Output.Printf("  Status: %s: %s\n", status.Status, status.Message)
+		}
+	}
+
+	entries, _ := os.ReadDir(k.OutputsDir)
+	var outputs []string
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(k.StatusFile) {
+			outputs = append(outputs, entry.Name())
+		}
+	}
+	if len(outputs) > 0 {
+		k.Output.Printf("  Outputs in %s:\n", k.OutputsDir)
+		for _, name := range outputs {
+			value, _ := os.ReadFile(filepath.Join(k.OutputsDir, name))
+			k.Output.Printf("    %s: %s\n", name, value)
+		}
+	}
+
+	if err != nil {
+		k.Output.Printf("  Result: failed with exit code %d: %s\n", ExitCode(err), err)
+	} else {
+		k.Output.Printf("  Result: succeeded\n")
+	}
+}
@@ -0,0 +1,245 @@
+package manual_approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_dryRunInit(t *testing.T) {
+	// Prepare
+	t.Setenv("TMPDIR", t.TempDir())
+	t.Setenv("API_TOKEN", "secret-token")
+	var output strings.Builder
+	c := Config{
+		Handler:      "init",
+		URL:          "http://test.com",
+		Approvers:    "123,user@mail.com",
+		Instructions: "Deploy?",
+		OutputMode:   "plain",
+		DryRun:       true,
+		Client: &MockHttpClient{
+			MockDo: func(req *http.Request) (*http.Response, error) {
+				t.Fatalf("unexpected request %s %s", req.Method, req.URL)
+				return nil, nil
+			},
+		},
+		Output: &MockStdOut{
+			MockPrintf:  func(format string, a ...any) { fmt.Fprintf(&output, format, a...) },
+			MockPrintln: func(a ...any) { fmt.Fprintln(&output, a...) },
+		},
+	}
+
+	// Run
+	err := c.Run(context.Background())
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, os.Getenv("TMPDIR"), filepath.Dir(c.OutputsDir))
+	out := output.String()
+	require.Contains(t, out, "DRY RUN: POST http://test.com/v1/workflows/approval\n")
+	require.Contains(t, out, "  Authorization: Bearer <redacted>\n")
+	require.NotContains(t, out, "secret-token")
+	require.Contains(t, out, `"approvers": [`)
+	require.Contains(t, out, "Waiting for approval from one of the following: 123,user@mail.com\n")
+	require.Contains(t, out, "Platform API requests that would have been sent:\n    POST http://test.com/v1/workflows/approval\n")
+	require.Contains(t, out, "  Status: PENDING_APPROVAL: Waiting for approval from approvers\n")
+	require.Contains(t, out, "  Result: succeeded\n")
+
+	data, err := os.ReadFile(c.StatusFile)
+	require.NoError(t, err)
+	status := StatusDocument{}
+	require.NoError(t, json.Unmarshal(data, &status))
+	require.Equal(t, "PENDING_APPROVAL", status.Status)
+}
+
+func Test_dryRunRequests(t *testing.T) {
+	tests := []struct {
+		name     string
+		config   Config
+		requests []string
+	}{
+		{
+			name:     "approve",
+			config:   Config{Handler: "approve", ApprovalID: "abc", Comments: "lgtm"},
+			requests: []string{"GET http://test.com/v1/workflows/approval/abc", "POST http://test.com/v1/workflows/approval/status"},
+		},
+		{
+			name:     "approval store",
+			config:   Config{Handler: "init", Approvers: "jane", ApprovalKey: imageDigest, ReuseWindow: "3h", ApprovalStore: "http://store.test.com/approvals"},
+			requests: []string{"GET http://store.test.com/approvals?approvalKey=sha256%3A4f53", "POST http://test.com/v1/workflows/approval"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			t.Setenv("TMPDIR", t.TempDir())
+			t.Setenv("API_TOKEN", "secret-token")
+			c := tt.config
+			c.URL = "http://test.com"
+			c.DryRun = true
+			c.Client = &MockHttpClient{
+				MockDo: func(req *http.Request) (*http.Response, error) {
+					t.Fatalf("unexpected request %s %s", req.Method, req.URL)
+					return nil, nil
+				},
+			}
+			c.Output = &MockStdOut{
+				MockPrintf:  func(format string, a ...any) {},
+				MockPrintln: func(a ...any) {},
+			}
+
+			// Run
+			err := c.Run(context.Background())
+
+			// Verify
+			require.NoError(t, err)
+			require.Equal(t, tt.requests, c.dryRunRequests)
+		})
+	}
+}
+
+func Test_dryRunFileBackend(t *testing.T) {
+	// Prepare
+	t.Setenv("TMPDIR", t.TempDir())
+	dir := filepath.Join(t.TempDir(), "approvals")
+	var output strings.Builder
+	c := Config{
+		Handler:     "wait",
+		Approvers:   "jane",
+		Inputs:      "env:\n  type: string\n  default: prod",
+		BackendType: BackendFile,
+		BackendDir:  dir,
+		OutputMode:  "plain",
+		DryRun:      true,
+		Output: &MockStdOut{
+			MockPrintf:  func(format string, a ...any) { fmt.Fprintf(&output, format, a...) },
+			MockPrintln: func(a ...any) { fmt.Fprintln(&output, a...) },
+		},
+	}
+
+	// Run
+	err := c.Run(context.Background())
+
+	// Verify
+	require.NoError(t, err)
+	require.NoDirExists(t, dir)
+	require.Len(t, c.dryRunFiles, 1)
+	require.Equal(t, dir, filepath.Dir(c.dryRunFiles[0]))
+	out := output.String()
+	require.Contains(t, out, "DRY RUN: write "+c.dryRunFiles[0]+"\n")
+	require.Contains(t, out, `"approvers": [`)
+	require.Contains(t, out, "  Files that would have been written:\n    "+c.dryRunFiles[0]+"\n")
+	require.Contains(t, out, "  Status: APPROVED: Successfully changed workflow manual approval status\n")
+
+	values, err := os.ReadFile(filepath.Join(c.OutputsDir, "approvalInputValues"))
+	require.NoError(t, err)
+	require.Equal(t, `{"env":"prod"}`, string(values))
+}
+
+func Test_dryRunFileBackendApprove(t *testing.T) {
+	// Prepare
+	t.Setenv("TMPDIR", t.TempDir())
+	dir := t.TempDir()
+	backend, err := newFileBackend(&Config{BackendDir: dir})
+	require.NoError(t, err)
+	created, err := backend.Create(context.Background(), map[string]interface{}{"approvers": []string{"jane"}})
+	require.NoError(t, err)
+	request, err := os.ReadFile(backend.requestFile(created.ID))
+	require.NoError(t, err)
+	c := Config{
+		Handler:     "approve",
+		ApprovalID:  created.ID,
+		BackendType: BackendFile,
+		BackendDir:  dir,
+		DryRun:      true,
+		Output: &MockStdOut{
+			MockPrintf:  func(format string, a ...any) {},
+			MockPrintln: func(a ...any) {},
+		},
+	}
+
+	// Run
+	err = c.Run(context.Background())
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, []string{backend.responseFile(created.ID)}, c.dryRunFiles)
+	require.NoFileExists(t, backend.responseFile(created.ID))
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	out, err := os.ReadFile(backend.requestFile(created.ID))
+	require.NoError(t, err)
+	require.Equal(t, string(request), string(out))
+}
+
+func Test_dryRunResponse(t *testing.T) {
+	tests := []struct {
+		name     string
+		method   string
+		handler  string
+		apiPath  string
+		body     string
+		inputs   string
+		expected string
+	}{
+		{
+			name:     "create",
+			method:   "POST",
+			apiPath:  "/v1/workflows/approval",
+			body:     `{"approvers":["jane"]}`,
+			expected: `{"id":"dry-run","approvers":[{"userName":"jane","userId":"","email":""}]}`,
+		},
+		{
+			name:     "list",
+			method:   "GET",
+			apiPath:  "/v1/workflows/approvals",
+			expected: `{"approvals":[]}`,
+		},
+		{
+			name:     "respond",
+			method:   "POST",
+			apiPath:  approvalStatusPath,
+			body:     `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED"}`,
+			expected: `{}`,
+		},
+		{
+			name:     "approval waiting for a response",
+			method:   "GET",
+			handler:  "approve",
+			apiPath:  "/v1/workflows/approval/a-1",
+			inputs:   "env:\n  type: string\n  default: prod",
+			expected: `{"id":"a-1","status":"PENDING_APPROVAL","approvers":null,"approvalInputs":"env:\n  type: string\n  default: prod","createdOn":"0001-01-01T00:00:00Z","expiresOn":"0001-01-01T00:00:00Z"}`,
+		},
+		{
+			name:     "waiting for the approval with default input values",
+			method:   "GET",
+			handler:  "wait",
+			apiPath:  "/v1/workflows/approval/a-1",
+			inputs:   "env:\n  type: string\n  default: prod\nreplicas:\n  type: number",
+			expected: `{"id":"a-1","status":"APPROVED","approvers":null,"approvalInputs":"env:\n  type: string\n  default: prod\nreplicas:\n  type: number","createdOn":"0001-01-01T00:00:00Z","expiresOn":"0001-01-01T00:00:00Z","userName":"dry-run","respondedOn":"2026-10-18T12:30:00Z","inputs":[{"is_default":true,"name":"env","value":"prod"}]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			c := Config{Handler: tt.handler, Inputs: tt.inputs, clock: func() time.Time { return time.Date(2026, 10, 18, 12, 30, 0, 0, time.UTC) }}
+
+			// Run
+			out, err := c.dryRunResponse(tt.method, tt.apiPath, []byte(tt.body))
+
+			// Verify
+			require.NoError(t, err)
+			require.JSONEq(t, tt.expected, out)
+		})
+	}
+}
@@ -19,13 +19,6 @@ import (
 
 var debug bool
 
-type RealHttpClient struct{}
-
-func (c *RealHttpClient) Do(req *http.Request) (*http.Response, error) {
-	http.DefaultClient.Timeout = 150 * time.Second
-	return http.DefaultClient.Do(req)
-}
-
 type RealStdOut struct{}
 
 func (c *RealStdOut) Printf(format string, a ...any) {
@@ -43,11 +36,35 @@ func init() {
 func (k *Config) Run(ctx context.Context) error {
 	k.Context = ctx
 
+	if k.Debug {
+		debug = true
+	}
+
 	// Use default std out if it is not already provided in the configuration
 	if k.Output == nil {
 		k.Output = &RealStdOut{}
 	}
 
+	outputMode, err := resolveOutputMode(k.OutputMode)
+	if err != nil {
+		return err
+	}
+	k.OutputMode = outputMode
+
+	if k.DryRun {
+		if err := k.startDryRun(); err != nil {
+			return err
+		}
+	}
+	err = k.handle()
+	if k.DryRun {
+		k.printDryRunSummary(err)
+	}
+	return err
+}
+
+// handle runs the handler selected in the configuration
+func (k *Config) handle() error {
 	switch k.Handler {
 	case "init":
 		return k.init()
@@ -55,58 +72,109 @@ func (k *Config) Run(ctx context.Context) error {
 		return k.callback()
 	case "cancel":
 		return k.cancel()
+	case "wait":
+		return k.wait()
+	case "validate":
+		return k.validate()
+	case "status":
+		return k.status()
+	case "list":
+		return k.list()
+	case "approve":
+		return k.respond(true)
+	case "reject":
+		return k.respond(false)
 	default:
-		return fmt.Errorf("unsupported handler type: %s", k.Handler)
+		return configErrorf("unsupported handler type: %s", k.Handler)
 	}
 }
 
 func (k *Config) defaultConfig() (string, string, error) {
 	debugf("Read default configuration from the environment variables\n")
 
-	apiUrl := os.Getenv("URL")
+	apiUrl := valueOrEnv(k.URL, "URL")
 	if apiUrl == "" {
-		return "", "", fmt.Errorf("URL environment variable missing")
+		return "", "", configErrorf("--url flag or URL environment variable missing")
 	}
 
-	apiToken := os.Getenv("API_TOKEN")
-	if apiToken == "" {
-		return "", "nil", fmt.Errorf("API_TOKEN environment variable missing")
+	tokenSource, err := k.tokenSource()
+	if err != nil {
+		return "", "", err
+	}
+	apiToken, err := tokenSource.Token(k.ctx())
+	if err != nil {
+		return "", "", err
 	}
 
 	return apiUrl, apiToken, nil
 }
 
+// valueOrEnv returns the value when it is set, otherwise the value of the environment variable
+func valueOrEnv(value string, key string) string {
+	if value != "" {
+		return value
+	}
+	return os.Getenv(key)
+}
+
 func (k *Config) init() error {
 	debugf("Inside init handler\n")
 
+	if reused, err := k.reuseApproval(); reused || err != nil {
+		return err
+	}
+
+	if _, err := k.requestApproval(); err != nil {
+		return err
+	}
+
+	return k.writeStatus("PENDING_APPROVAL", "Waiting for approval from approvers")
+}
+
+// requestApproval creates the manual approval request and logs who it is waiting for
+func (k *Config) requestApproval() (*CreateManualApprovalResponse, error) {
 	// approvers are optional
-	approvers := os.Getenv("APPROVERS")
+	approvers := valueOrEnv(k.Approvers, "APPROVERS")
 
 	// instructions are optional
-	instructions := os.Getenv("INSTRUCTIONS")
+	instructions := valueOrEnv(k.Instructions, "INSTRUCTIONS")
 
 	// by default disallowLaunchedByUser is false
-	disallowLaunchedByUserStr := os.Getenv("DISALLOW_LAUNCHED_BY_USER")
+	disallowLaunchedByUserStr := valueOrEnv(k.DisallowLaunchedByUser, "DISALLOW_LAUNCHED_BY_USER")
 	if disallowLaunchedByUserStr == "" {
 		disallowLaunchedByUserStr = "false"
 	}
 	disallowLaunchedByUser, err := strconv.ParseBool(disallowLaunchedByUserStr)
 	if err != nil {
-		return err
+		return nil, configErrorf("%w", err)
 	}
 
 	// by default notifyAllEligibleUsers is false
-	notifyStr := os.Getenv("NOTIFY_ALL_ELIGIBLE_USERS")
+	notifyStr := valueOrEnv(k.NotifyAllEligibleUsers, "NOTIFY_ALL_ELIGIBLE_USERS")
 	if notifyStr == "" {
 		notifyStr = "false"
 	}
 	notify, err := strconv.ParseBool(notifyStr)
 	if err != nil {
-		return err
+		return nil, configErrorf("%w", err)
 	}
 
 	// get approvalInputs if configured for the manual approval job
-	inputs := os.Getenv("INPUTS")
+	inputs := valueOrEnv(k.Inputs, "INPUTS")
+	schema, warnings, err := checkApprovalInputs(inputs)
+	if err == nil {
+		err = k.checkInputOutputNames(schema, nil)
+	}
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", err), err)
+		if ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	for _, warning := range warnings {
+		k.Output.Printf("WARNING: approvalInputs %s; the attribute is ignored\n", warning)
+	}
 
 	// Construct request body
 	body := map[string]interface{}{
@@ -114,8 +182,26 @@ func (k *Config) init() error {
 		"notifyEligibleUsers":    notify,
 	}
 
+	var approverList []string
 	if approvers != "" {
-		body["approvers"] = strings.Split(approvers, ",")
+		approverList = strings.Split(approvers, ",")
+	}
+	err = k.resolveDisallowedAuthors()
+	if err == nil {
+		approverList, err = k.delegateApprovers(approverList)
+	}
+	if err == nil {
+		approverList, err = k.removeDisallowedApprovers(approverList)
+	}
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", err), err)
+		if ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+	if len(approverList) > 0 {
+		body["approvers"] = approverList
 	}
 
 	if instructions != "" {
@@ -126,25 +212,33 @@ func (k *Config) init() error {
 		body["approvalInputs"] = inputs
 	}
 
-	resp, err := k.post("/v1/workflows/approval", body)
+	policy, err := k.rejectionPolicy()
 	if err != nil {
-		k.Output.Printf("ERROR: API call failed with error: '%s'\n", err)
-		k.Output.Printf("ERROR: API response: '%s'\n", resp)
-		ferr := writeStatus("FAILED", fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", err))
+		ferr := k.writeErrorStatus(fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", err), err)
 		if ferr != nil {
-			return ferr
+			return nil, ferr
 		}
-		return err
+		return nil, err
 	}
-	debugf("Response: '%s'\n", resp)
+	policy.addTo(body)
 
-	//get the names of potential approvers from the response
-	parsedResp := CreateManualApprovalResponse{}
-	err = json.Unmarshal([]byte(resp), &parsedResp)
+	backend, err := k.backend()
 	if err != nil {
-		return err
+		return nil, err
 	}
+	parsedResp, err := backend.Create(k.ctx(), body)
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", errorMessage(err)), err)
+		if ferr != nil {
+			return nil, ferr
+		}
+		return nil, err
+	}
+
+	k.statusDetails.approvalID = parsedResp.ID
+	k.statusDetails.requestedOn = k.now()
 
+	//get the names of potential approvers from the response
 	users := make([]string, len(parsedResp.Approvers))
 	for i, approver := range parsedResp.Approvers {
 		users[i] = approver.UserName
@@ -152,24 +246,24 @@ func (k *Config) init() error {
 
 	k.Output.Printf("Waiting for approval from one of the following: %s\n", strings.Join(users, ","))
 	if instructions != "" {
-		k.Output.Printf("Instructions:\n%s\n", markdown(instructions))
+		k.Output.Printf("Instructions:\n%s\n", k.renderInstructions(instructions))
 	}
 
-	return writeStatus("PENDING_APPROVAL", "Waiting for approval from approvers")
+	return parsedResp, nil
 }
 
 func (k *Config) callback() error {
 	debugf("Inside callback handler\n")
 
-	payload := os.Getenv("PAYLOAD")
+	payload := valueOrEnv(k.Payload, "PAYLOAD")
 	if payload == "" {
-		return fmt.Errorf("PAYLOAD environment variable missing")
+		return configErrorf("--payload flag or PAYLOAD environment variable missing")
 	}
 
 	debugf("Incoming payload: '%s'\n", payload)
 
 	parsedPayload := map[string]interface{}{}
-	err := json.Unmarshal([]byte(payload), &parsedPayload)
+	err := decodeJSON([]byte(payload), &parsedPayload)
 	if err != nil {
 		return err
 	}
@@ -186,40 +280,112 @@ func (k *Config) callback() error {
 	approverUserName := parsedPayload["userName"].(string)
 	debugf("Approver user name: '%s'\n", approverUserName)
 
+	reasonCode, _ := parsedPayload["reasonCode"].(string)
+	debugf("Reason code: '%s'\n", reasonCode)
+
+	if id, ok := parsedPayload["id"].(string); ok {
+		k.statusDetails.approvalID = id
+		k.readRequestedOn(id)
+	}
+
+	inputs, _ := parsedPayload["inputs"].([]interface{})
+	inputs, err = k.checkInputValues(inputs, approvalStatus == "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED")
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Invalid approval input values: '%s'", err), err)
+		if ferr != nil {
+			return ferr
+		}
+		return err
+	}
+	if inputs != nil {
+		parsedPayload["inputs"] = inputs
+	}
+
+	if err := k.checkRejectionPolicy(approvalStatus, comments, reasonCode); err != nil {
+		return err
+	}
+	approverUserID, _ := parsedPayload["userId"].(string)
+	approverEmail, _ := parsedPayload["email"].(string)
+	if err := k.checkApproverAllowed(approvalStatus, approverUserName, approverUserID, approverEmail); err != nil {
+		return err
+	}
+	k.statusDetails.approverID = approverUserID
+
 	// POST request expects input param values to be strings, so converting values to string
 	// Also, creating a map with input values in original type to be made available in outputs
 	modifiedInputsParamForPost, outputsMap, err4 := formatInputsForPost(parsedPayload)
 	if err4 != nil {
 		return err4
 	}
+	if err := k.checkInputOutputNames(k.inputSchema, outputsMap); err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Invalid approval input values: '%s'", err), err)
+		if ferr != nil {
+			return ferr
+		}
+		return err
+	}
+
+	backend, err := k.backend()
+	if err != nil {
+		return err
+	}
+	err = backend.UpdateStatus(k.ctx(), parsedPayload)
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Failed to change workflow manual approval status: '%s'", errorMessage(err)), err)
+		if ferr != nil {
+			return ferr
+		}
+		return err
+	}
+
+	return k.completeApproval(approvalStatus, approverUserName, respondedOn, comments, reasonCode, modifiedInputsParamForPost, outputsMap)
+}
 
-	resp, err := k.post("/v1/workflows/approval/status", parsedPayload)
+// checkRejectionPolicy fails the job when a rejection does not give the comment or the reason code
+// the policy asks for
+func (k *Config) checkRejectionPolicy(approvalStatus string, comments string, reasonCode string) error {
+	policy, err := k.rejectionPolicy()
+	if err == nil {
+		err = policy.check(approvalStatus, comments, reasonCode)
+	}
 	if err != nil {
-		k.Output.Printf("ERROR: API call failed with error: '%s'\n", err)
-		k.Output.Printf("ERROR: API response: '%s'\n", resp)
-		ferr := writeStatus("FAILED", fmt.Sprintf("Failed to change workflow manual approval status: '%s'", err))
+		ferr := k.writeErrorStatus(fmt.Sprintf("Invalid approval decision: '%s'", err), err)
 		if ferr != nil {
 			return ferr
 		}
 		return err
 	}
-	debugf("Response: '%s'\n", resp)
+	return nil
+}
 
+// completeApproval writes the approver decision to the log, the outputs and the job status
+func (k *Config) completeApproval(approvalStatus string, approverUserName string, respondedOn string, comments string,
+	reasonCode string, modifiedInputsParamForPost []interface{}, outputsMap map[string]interface{}) error {
 	jobStatus, err2 := k.processApprovalStatus(approvalStatus, approverUserName, respondedOn, comments)
 	if err2 != nil {
 		return err2
 	}
+	k.statusDetails.decision = jobStatus
+	k.statusDetails.approver = approverUserName
+	k.statusDetails.respondedOn = respondedOn
+	k.statusDetails.reasonCode = reasonCode
+	if reasonCode != "" {
+		k.Output.Printf("Reason: %s\n", reasonCode)
+	}
 
 	// Add suffix for default vals and write to log
 	k.formatInputsValsAndWriteToLog(modifiedInputsParamForPost)
 
 	//
-	err3 := k.writeToOutputs(outputsMap, comments)
+	err3 := k.writeToOutputs(outputsMap, comments, reasonCode)
 	if err3 != nil {
 		return err3
 	}
+	if err := k.writeDecisionRecord(); err != nil {
+		return err
+	}
 
-	return writeStatus(jobStatus, "Successfully changed workflow manual approval status")
+	return k.writeStatus(jobStatus, "Successfully changed workflow manual approval status")
 }
 
 /*
@@ -240,7 +406,10 @@ func formatInputsForPost(parsedPayload map[string]interface{}) ([]interface{}, m
 			// To print input param values in original type to outputs
 			outputsMap[ip["name"].(string)] = ip["value"]
 			// Converting param value to string type for POST request
-			inputVal := interfaceToString(ip["value"])
+			inputVal, err := valueToString(ip["value"])
+			if err != nil {
+				return nil, nil, validationErrorf("invalid value for input '%s': %w", ip["name"], err)
+			}
 			ip["value"] = inputVal
 		}
 		parsedPayload["inputs"] = modifiedInputsParamForPost
@@ -258,25 +427,28 @@ func formatInputsForPost(parsedPayload map[string]interface{}) ([]interface{}, m
 	return modifiedInputsParamForPost, outputsMap, nil
 }
 
-func (k *Config) writeToOutputs(outputsMap map[string]interface{}, comments string) error {
+func (k *Config) writeToOutputs(outputsMap map[string]interface{}, comments string, reasonCode string) error {
 
 	if outputsMap != nil {
+		if err := k.writeInputOutputs(outputsMap); err != nil {
+			return err
+		}
 		outputBytes, err := json.Marshal(outputsMap)
 		if err != nil {
 			return err
 		}
-		err = writeAsOutput("approvalInputValues", outputBytes)
+		err = k.writeAsOutput("approvalInputValues", outputBytes)
 		if err != nil {
 			return err
 		}
 		debugf("Approval Input Values in outputs: '%s'\n", string(outputBytes))
 	}
 
-	err := writeAsOutput("comments", []byte(comments))
+	err := k.writeAsOutput("comments", []byte(comments))
 	if err != nil {
 		return err
 	}
-	return nil
+	return k.writeAsOutput("reasonCode", []byte(reasonCode))
 }
 
 // Add suffix if input param value is default value before writing it to callback handler logs
@@ -285,17 +457,27 @@ func (k *Config) formatInputsValsAndWriteToLog(modifiedInputsParamForPost []inte
 		k.Output.Printf("\nInput Parameters:\n")
 		k.Output.Printf("------------------\n")
 		suffix := " (default)"
+		var rows [][2]string
 		for _, input := range modifiedInputsParamForPost {
 			ip := input.(map[string]interface{})
-			inputVal := ip["value"].(string)
-			inputVal = strings.Replace(inputVal, "\n", "<br/>", -1) // replace /n with <br> for html rendering
+			inputVal := k.displayInputValue(ip["name"].(string), ip["value"].(string))
+			if k.outputMode() == OutputModeHTML {
+				inputVal = strings.Replace(inputVal, "\n", "<br/>", -1) // replace /n with <br> for html rendering
+			}
 			if ip["is_default"] == true {
 				inputVal += suffix
 			}
 
+			if k.outputMode() != OutputModeHTML {
+				rows = append(rows, [2]string{ip["name"].(string), inputVal})
+				continue
+			}
 			k.Output.Printf(" %s: %s \n",
 				ip["name"], inputVal)
 		}
+		for _, line := range k.formatInputsTable(rows) {
+			k.Output.Printf("%s", line)
+		}
 	}
 }
 
@@ -310,63 +492,72 @@ func (k *Config) processApprovalStatus(approvalStatus string, approverUserName s
 		k.Output.Printf("Rejected by %s on %s with comments:\n%s\n", approverUserName, respondedOn, comments)
 	default:
 		k.Output.Printf("ERROR: Unexpected approval status '%s'\n", approvalStatus)
-		ferr := writeStatus("FAILED", fmt.Sprintf("Unexpected approval status '%s'", approvalStatus))
+		err := validationErrorf("Unexpected approval status '%s'", approvalStatus)
+		ferr := k.writeErrorStatus(err.Error(), err)
 		if ferr != nil {
 			return "", ferr
 		}
-		return "", fmt.Errorf("Unexpected approval status '%s'", approvalStatus)
+		return "", err
 	}
 	return jobStatus, nil
 }
 
-func interfaceToString(i interface{}) string {
-	switch v := i.(type) {
-	case string:
-		return v
-	case int:
-		return strconv.FormatInt(int64(v), 64)
-	case float64:
-		return strconv.FormatFloat(v, 'g', -1, 64)
-	case bool:
-		return strconv.FormatBool(v)
-	default:
-		return "unsupported type"
-	}
-}
-
 func (k *Config) cancel() error {
 	debugf("Inside cancel handler\n")
 
-	cancellationReason := os.Getenv("CANCELLATION_REASON")
+	cancellationReason := valueOrEnv(k.CancellationReason, "CANCELLATION_REASON")
 	if cancellationReason == "" {
-		return fmt.Errorf("CANCELLATION_REASON environment variable missing")
+		return configErrorf("--reason flag or CANCELLATION_REASON environment variable missing")
 	}
 
-	// Construct request body
-	body := map[string]interface{}{}
+	return k.cancelApproval(cancellationReason, "")
+}
+
+// cancelApproval marks the approval request as aborted when the reason is CANCELLED and as timed out
+// otherwise. The approval ID is optional, the platform uses the request of the running job without it.
+func (k *Config) cancelApproval(cancellationReason string, id string) error {
+	backend, err := k.backend()
+	if err != nil {
+		return err
+	}
+
+	status := ApprovalStatusTimedOut
 	if cancellationReason == "CANCELLED" {
 		k.Output.Println("Workflow aborted by user")
 		k.Output.Println("Cancelling the manual approval request")
-		body["status"] = "UPDATE_MANUAL_APPROVAL_STATUS_ABORTED"
+		status = ApprovalStatusAborted
 	} else {
 		k.Output.Println("Workflow timed out")
 		k.Output.Println("Workflow approval response was not received within allotted time.")
-		body["status"] = "UPDATE_MANUAL_APPROVAL_STATUS_TIMED_OUT"
 	}
 
-	resp, err := k.post("/v1/workflows/approval/status", body)
+	// The request is cancelled because the run is, so the call must not use the cancelled context
+	return backend.Cancel(context.WithoutCancel(k.ctx()), id, status)
+}
+
+func (k *Config) post(ctx context.Context, apiPath string, requestBody map[string]interface{}) (string, error) {
+	debugf("Post http request to the platform API endpoint: '%s'\n", apiPath)
+
+	// Prepare JSON request body for REST API call
+	body, err := json.Marshal(&requestBody)
 	if err != nil {
-		k.Output.Printf("ERROR: API call failed with error: '%s'\n", err)
-		k.Output.Printf("ERROR: API response: '%s'\n", resp)
-		return err
+		return "", err
 	}
-	debugf("Response: '%s'\n", resp)
+	debugf("Payload: '%s'\n", string(body))
 
-	return nil
+	return k.send(ctx, "POST", apiPath, nil, body)
 }
 
-func (k *Config) post(apiPath string, requestBody map[string]interface{}) (string, error) {
-	debugf("Post http request to the platform API endpoint: '%s'\n", apiPath)
+func (k *Config) get(ctx context.Context, apiPath string, query url.Values) (string, error) {
+	debugf("Get http request to the platform API endpoint: '%s'\n", apiPath)
+
+	return k.send(ctx, "GET", apiPath, query, nil)
+}
+
+func (k *Config) send(ctx context.Context, method string, apiPath string, query url.Values, body []byte) (string, error) {
+	if k.DryRun {
+		return k.dryRun(ctx, method, apiPath, query, body)
+	}
 
 	// Read default configuration from the environment variables
 	apiUrl, apiToken, err := k.defaultConfig()
@@ -379,35 +570,36 @@ func (k *Config) post(apiPath string, requestBody map[string]interface{}) (strin
 	if err != nil {
 		return "", err
 	}
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
 
-	// Prepare JSON request body for REST API call
-	body, err := json.Marshal(&requestBody)
+	client, err := k.httpClient()
 	if err != nil {
 		return "", err
 	}
-	debugf("Payload: '%s'\n", string(body))
-
-	// Use default http client if it is not already provided in the configuration
-	if k.Client == nil {
-		k.Client = &RealHttpClient{}
-	}
 
-	apiReq, err := http.NewRequest(
-		"POST",
-		requestURL,
-		bytes.NewReader(body),
-	)
+	resp, err := k.do(ctx, client, method, requestURL, apiToken, body)
 	if err != nil {
 		return "", err
 	}
 
-	apiReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiToken))
-	apiReq.Header.Set("Content-Type", "application/json")
-	apiReq.Header.Set("Accept", "application/json")
-
-	resp, err := k.Client.Do(apiReq)
-	if err != nil {
-		return "", err
+	// The token may have expired or been rotated, refresh it once and retry with the new one
+	if resp.StatusCode == http.StatusUnauthorized {
+		tokenSource, err := k.tokenSource()
+		if err != nil {
+			return "", err
+		}
+		tokenSource.Invalidate()
+		refreshedToken, err := tokenSource.Token(ctx)
+		if err == nil && refreshedToken != apiToken {
+			debugf("Retry the request with a refreshed token\n")
+			_ = resp.Body.Close()
+			resp, err = k.do(ctx, client, method, requestURL, refreshedToken, body)
+			if err != nil {
+				return "", err
+			}
+		}
 	}
 	defer func() { _ = resp.Body.Close() }()
 
@@ -419,12 +611,58 @@ func (k *Config) post(apiPath string, requestBody map[string]interface{}) (strin
 	response := string(responseBody)
 
 	if resp.StatusCode != 200 {
-		return response, fmt.Errorf("failed to send event: \nPOST %s\nHTTP/%d %s\n", requestURL, resp.StatusCode, resp.Status)
+		return response, newAPIError(resp, method, requestURL, apiPath, response)
 	}
 
 	return response, nil
 }
 
+func (k *Config) do(ctx context.Context, client HttpClient, method string, requestURL string, apiToken string, body []byte) (*http.Response, error) {
+	apiReq, err := newRequest(ctx, method, requestURL, apiToken, body)
+	if err != nil {
+		return nil, err
+	}
+	return client.Do(apiReq)
+}
+
+// newRequest builds a platform API request
+func newRequest(ctx context.Context, method string, requestURL string, apiToken string, body []byte) (*http.Request, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	apiReq, err := http.NewRequestWithContext(
+		ctx,
+		method,
+		requestURL,
+		bodyReader,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	apiReq.Header.Set("Authorization", fmt.Sprintf("Bearer %s", apiToken))
+	if body != nil {
+		apiReq.Header.Set("Content-Type", "application/json")
+	}
+	apiReq.Header.Set("Accept", "application/json")
+
+	return apiReq, nil
+}
+
+// httpClient returns the HTTP client of the configuration, the default client is built from the
+// transport settings when it is not set
+func (k *Config) httpClient() (HttpClient, error) {
+	if k.Client == nil {
+		client, err := NewHttpClient(k)
+		if err != nil {
+			return nil, err
+		}
+		k.Client = client
+	}
+	return k.Client, nil
+}
+
 func debugf(format string, a ...any) {
 	if debug {
 		t := time.Now()
@@ -432,10 +670,10 @@ func debugf(format string, a ...any) {
 	}
 }
 
-func writeAsOutput(name string, value []byte) error {
-	outputsDir := os.Getenv("CLOUDBEES_OUTPUTS")
+func (k *Config) writeAsOutput(name string, value []byte) error {
+	outputsDir := valueOrEnv(k.OutputsDir, "CLOUDBEES_OUTPUTS")
 	if outputsDir == "" {
-		return fmt.Errorf("CLOUDBEES_OUTPUTS environment variable missing")
+		return configErrorf("--outputs-dir flag or CLOUDBEES_OUTPUTS environment variable missing")
 	}
 
 	outputFile := filepath.Join(outputsDir, name)
@@ -446,27 +684,6 @@ func writeAsOutput(name string, value []byte) error {
 	return nil
 }
 
-func writeStatus(status string, message string) error {
-	statusFile := os.Getenv("CLOUDBEES_STATUS")
-	if statusFile == "" {
-		return fmt.Errorf("CLOUDBEES_STATUS environment variable missing")
-	}
-	output := map[string]interface{}{
-		"status":  status,
-		"message": message,
-	}
-
-	outputBytes, err := json.Marshal(&output)
-	if err != nil {
-		return err
-	}
-	err = os.WriteFile(statusFile, outputBytes, 0666)
-	if err != nil {
-		return fmt.Errorf("failed to write to %s: %w", statusFile, err)
-	}
-	return nil
-}
-
 // Add markdown format support to instructions
 func markdown(value string) string {
 	var buf bytes.Buffer
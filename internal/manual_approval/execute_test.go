@@ -7,6 +7,7 @@ import (
 	"io"
 	"net/http"
 	"os"
+	"path/filepath"
 	"slices"
 	"testing"
 
@@ -58,12 +59,12 @@ func Test_defaultConfig(t *testing.T) {
 		{
 			name: "no API_TOKEN environment variable",
 			env:  map[string]string{"URL": "http://test.com"},
-			err:  "API_TOKEN environment variable missing",
+			err:  "--token-file flag or API_TOKEN environment variable missing",
 		},
 		{
 			name: "no URL environment variable",
 			env:  map[string]string{},
-			err:  "URL environment variable missing",
+			err:  "--url flag or URL environment variable missing",
 		},
 	}
 	for _, tt := range tests {
@@ -93,6 +94,61 @@ func Test_defaultConfig(t *testing.T) {
 	}
 }
 
+func Test_defaultConfigTokenFile(t *testing.T) {
+	tokenFile := t.TempDir() + "/token"
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+	emptyTokenFile := t.TempDir() + "/empty"
+	require.NoError(t, os.WriteFile(emptyTokenFile, []byte("\n"), 0600))
+
+	tests := []struct {
+		name   string
+		config Config
+		env    map[string]string
+		token  string
+		err    string
+	}{
+		{
+			name:   "token file flag",
+			config: Config{URL: "http://test.com", TokenFile: tokenFile},
+			env:    map[string]string{"API_TOKEN": "env-token"},
+			token:  "file-token",
+		},
+		{
+			name:  "token file environment variable",
+			env:   map[string]string{"URL": "http://test.com", "API_TOKEN_FILE": tokenFile},
+			token: "file-token",
+		},
+		{
+			name:   "empty token file",
+			config: Config{URL: "http://test.com", TokenFile: emptyTokenFile},
+			err:    "API token file " + emptyTokenFile + " is empty",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer func(k string) {
+					os.Unsetenv(k)
+				}(k)
+			}
+
+			// Run
+			_, apiToken, err := tt.config.defaultConfig()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tt.token, apiToken)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+		})
+	}
+}
+
 func Test_init(t *testing.T) {
 	tests := []struct {
 		name         string
@@ -369,9 +425,7 @@ func Test_init(t *testing.T) {
 			// Verify
 			if tt.err == "" {
 				require.NoError(t, err)
-				out, ferr := os.ReadFile(tt.env["CLOUDBEES_STATUS"])
-				require.NoError(t, ferr)
-				require.Equal(t, "{\"message\":\"Waiting for approval from approvers\",\"status\":\"PENDING_APPROVAL\"}", string(out))
+				requireStatusFile(t, "{\"message\":\"Waiting for approval from approvers\",\"status\":\"PENDING_APPROVAL\"}", tt.env["CLOUDBEES_STATUS"])
 			} else {
 				require.Error(t, err)
 				require.Equal(t, tt.err, err.Error())
@@ -629,9 +683,7 @@ func Test_callback(t *testing.T) {
 				require.Equal(t, tt.commentsInOutput, string(out))
 			}
 
-			out, ferr := os.ReadFile(tt.env["CLOUDBEES_STATUS"])
-			require.NoError(t, ferr)
-			require.Equal(t, tt.statusInFile, string(out))
+			requireStatusFile(t, tt.statusInFile, tt.env["CLOUDBEES_STATUS"])
 
 			require.True(t, slices.Equal(tt.output, testOutput))
 		})
@@ -809,3 +861,56 @@ func Test_markdown(t *testing.T) {
 		})
 	}
 }
+
+// Test_handlerCassettes replays the platform API exchanges the hand-built MockHttpClient tests above
+// do not cover
+func Test_handlerCassettes(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      Config
+		run         func(c *Config) error
+		status      string
+		requestedOn string
+		elapsed     int64
+	}{
+		{
+			name: "callback-approved",
+			config: Config{
+				Payload: `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"lgtm","userId":"123","userName":"testUserName","respondedOn":"2026-10-18T12:30:00Z","inputs":[{"name":"in1","value":"prod"},{"name":"in2","value":3}]}`,
+			},
+			run:         (*Config).callback,
+			status:      `{"message":"Successfully changed workflow manual approval status","status":"APPROVED"}`,
+			requestedOn: "2026-10-18T12:10:00Z",
+			elapsed:     1200,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			cassette := newCassette(t, tt.name)
+			c := tt.config
+			c.URL = cassette.url()
+			c.Client = cassette
+			c.OutputsDir = dir
+			c.StatusFile = filepath.Join(dir, "status")
+			c.Output = &MockStdOut{
+				MockPrintf:  func(format string, a ...any) {},
+				MockPrintln: func(a ...any) {},
+			}
+
+			// Run
+			err := tt.run(&c)
+
+			// Verify
+			require.NoError(t, err)
+			requireStatusFile(t, tt.status, c.StatusFile)
+			out, err := os.ReadFile(c.StatusFile)
+			require.NoError(t, err)
+			status := StatusDocument{}
+			require.NoError(t, json.Unmarshal(out, &status))
+			require.Equal(t, tt.requestedOn, status.RequestedOn)
+			require.Equal(t, &tt.elapsed, status.ElapsedSeconds)
+		})
+	}
+}
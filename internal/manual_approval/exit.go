@@ -0,0 +1,129 @@
+package manual_approval
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Process exit codes
+const (
+	ExitOK         = 0
+	ExitFailure    = 1
+	ExitRejected   = 2
+	ExitTimedOut   = 3
+	ExitCancelled  = 4
+	ExitConfig     = 5
+	ExitValidation = 6
+	ExitAPIClient  = 7
+	ExitAPIServer  = 8
+)
+
+// ExitError is an error that ends the process with a specific exit code
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ConfigError is a missing or invalid setting of the configuration
+type ConfigError struct {
+	Err error
+}
+
+func (e *ConfigError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+func configErrorf(format string, a ...any) error {
+	return &ConfigError{Err: fmt.Errorf(format, a...)}
+}
+
+// ValidationError is an invalid approval input, response or job configuration
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func validationErrorf(format string, a ...any) error {
+	return &ValidationError{Err: fmt.Errorf(format, a...)}
+}
+
+// Error classes of the status document, each ending the process with its own exit code
+const (
+	ErrorClassFailure    = "failure"
+	ErrorClassRejected   = "rejected"
+	ErrorClassTimedOut   = "timed-out"
+	ErrorClassCancelled  = "cancelled"
+	ErrorClassConfig     = "config"
+	ErrorClassValidation = "validation"
+	ErrorClassAPIClient  = "api-client"
+	ErrorClassAPIServer  = "api-server"
+)
+
+var errorClassExitCodes = map[string]int{
+	ErrorClassFailure:    ExitFailure,
+	ErrorClassRejected:   ExitRejected,
+	ErrorClassTimedOut:   ExitTimedOut,
+	ErrorClassCancelled:  ExitCancelled,
+	ErrorClassConfig:     ExitConfig,
+	ErrorClassValidation: ExitValidation,
+	ErrorClassAPIClient:  ExitAPIClient,
+	ErrorClassAPIServer:  ExitAPIServer,
+}
+
+// errorClass classifies the error returned by a handler
+func errorClass(err error) string {
+	var exitErr *ExitError
+	var apiErr *APIError
+	var configErr *ConfigError
+	var validationErr *ValidationError
+	var inputErrs InputErrors
+	switch {
+	case errors.As(err, &exitErr) && exitErr.Code == ExitRejected:
+		return ErrorClassRejected
+	case errors.As(err, &exitErr) && exitErr.Code == ExitTimedOut:
+		return ErrorClassTimedOut
+	case errors.As(err, &exitErr) && exitErr.Code == ExitCancelled, errors.Is(err, context.Canceled):
+		return ErrorClassCancelled
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 500:
+		return ErrorClassAPIServer
+	case errors.As(err, &apiErr) && apiErr.StatusCode >= 400:
+		return ErrorClassAPIClient
+	case errors.As(err, &configErr):
+		return ErrorClassConfig
+	case errors.As(err, &validationErr), errors.As(err, &inputErrs):
+		return ErrorClassValidation
+	}
+	return ErrorClassFailure
+}
+
+// ExitCode returns the process exit code for the error returned by Run
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	return errorClassExitCodes[errorClass(err)]
+}
@@ -0,0 +1,394 @@
+package manual_approval
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const BackendFile = "file"
+
+// fileRequest is the request file written by the file backend
+type fileRequest struct {
+	ID                     string      `json:"id"`
+	Status                 string      `json:"status"`
+	Approvers              []string    `json:"approvers,omitempty"`
+	Instructions           string      `json:"instructions,omitempty"`
+	ApprovalInputs         string      `json:"approvalInputs,omitempty"`
+	Inputs                 []fileInput `json:"inputs,omitempty"`
+	DisallowLaunchedByUser bool        `json:"disallowLaunchedByUser"`
+	NotifyEligibleUsers    bool        `json:"notifyEligibleUsers"`
+	RejectionReasons       []string    `json:"rejectionReasons,omitempty"`
+	CreatedOn              time.Time   `json:"createdOn"`
+}
+
+// fileInput is an input of the approvalInputs definition as written to the request file
+type fileInput struct {
+	Name         string      `json:"name"`
+	Type         string      `json:"type"`
+	Description  string      `json:"description,omitempty"`
+	Required     bool        `json:"required,omitempty"`
+	Default      interface{} `json:"default,omitempty"`
+	Options      []string    `json:"options,omitempty"`
+	Pattern      string      `json:"pattern,omitempty"`
+	MinLength    *int        `json:"minLength,omitempty"`
+	MaxLength    *int        `json:"maxLength,omitempty"`
+	Minimum      json.Number `json:"minimum,omitempty"`
+	Maximum      json.Number `json:"maximum,omitempty"`
+	ErrorMessage string      `json:"errorMessage,omitempty"`
+
+	// When maps the inputs this input depends on to the values for which it applies
+	When map[string][]string `json:"when,omitempty"`
+}
+
+// fileResponse is the response file dropped next to the request file by the approver
+type fileResponse struct {
+	ID          string                 `json:"id"`
+	Decision    string                 `json:"decision"`
+	Approver    string                 `json:"approver"`
+	Comments    string                 `json:"comments,omitempty"`
+	ReasonCode  string                 `json:"reasonCode,omitempty"`
+	RespondedOn string                 `json:"respondedOn"`
+	Inputs      map[string]interface{} `json:"inputs,omitempty"`
+}
+
+// fileBackend exchanges approval requests and responses as JSON files in a directory, for
+// approvals made by committing a file or dropping one into a shared volume
+type fileBackend struct {
+	k   *Config
+	dir string
+
+	// dryRunRequests are the request files kept in memory instead of written in a dry run
+	dryRunRequests map[string]*fileRequest
+}
+
+func newFileBackend(k *Config) (*fileBackend, error) {
+	dir := valueOrEnv(k.BackendDir, "APPROVAL_DIR")
+	if dir == "" {
+		return nil, configErrorf("--backend-dir flag or APPROVAL_DIR environment variable missing")
+	}
+	return &fileBackend{k: k, dir: dir}, nil
+}
+
+func (b *fileBackend) requestFile(id string) string {
+	return filepath.Join(b.dir, id+".request.json")
+}
+
+func (b *fileBackend) responseFile(id string) string {
+	return filepath.Join(b.dir, id+".response.json")
+}
+
+func (b *fileBackend) Create(_ context.Context, request map[string]interface{}) (*CreateManualApprovalResponse, error) {
+	debugf("Inside file backend\n")
+
+	approvalInputs, _ := request["approvalInputs"].(string)
+	schema, err := usableApprovalInputs(approvalInputs)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := b.k.rejectionPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := newApprovalID()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &fileRequest{
+		ID:               id,
+		Status:           ApprovalStatusPending,
+		ApprovalInputs:   approvalInputs,
+		RejectionReasons: policy.reasons,
+		CreatedOn:        b.k.now().UTC(),
+	}
+	req.Instructions, _ = request["instructions"].(string)
+	req.Approvers, _ = request["approvers"].([]string)
+	req.DisallowLaunchedByUser, _ = request["disallowLaunchedByUser"].(bool)
+	req.NotifyEligibleUsers, _ = request["notifyEligibleUsers"].(bool)
+	for _, input := range schema {
+		var when map[string][]string
+		for _, condition := range input.When {
+			if when == nil {
+				when = map[string][]string{}
+			}
+			when[condition.Input] = condition.Values
+		}
+		req.Inputs = append(req.Inputs, fileInput{
+			Name:         input.Name,
+			Type:         input.Type,
+			Description:  input.Description,
+			Required:     input.Required,
+			Default:      input.Default,
+			Options:      input.Options,
+			Pattern:      input.Pattern,
+			MinLength:    input.MinLength,
+			MaxLength:    input.MaxLength,
+			Minimum:      input.Minimum,
+			Maximum:      input.Maximum,
+			ErrorMessage: input.ErrorMessage,
+			When:         when,
+		})
+	}
+
+	if err := b.writeRequest(req); err != nil {
+		return nil, err
+	}
+	debugf("Request file: '%s'\n", b.requestFile(id))
+
+	resp := &CreateManualApprovalResponse{ID: id}
+	for _, approver := range req.Approvers {
+		resp.Approvers = append(resp.Approvers, Approvers{UserName: approver})
+	}
+	return resp, nil
+}
+
+// Get returns the approval request, answered by the response file once it has been dropped
+func (b *fileBackend) Get(_ context.Context, id string) (*ApprovalRequest, error) {
+	req, err := b.readRequest(id)
+	if err != nil {
+		return nil, err
+	}
+
+	approval := &ApprovalRequest{
+		ID:             req.ID,
+		Status:         req.Status,
+		Instructions:   req.Instructions,
+		ApprovalInputs: req.ApprovalInputs,
+		CreatedOn:      req.CreatedOn,
+	}
+	for _, approver := range req.Approvers {
+		approval.Approvers = append(approval.Approvers, Approvers{UserName: approver})
+	}
+	if req.Status != ApprovalStatusPending {
+		return approval, nil
+	}
+	if b.dryRunRequests[id] != nil {
+		b.k.dryRunDecision(approval)
+		return approval, nil
+	}
+
+	path := b.responseFile(id)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return approval, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// A response file that is not complete JSON may still be written, it is read again on the next poll
+	if !json.Valid(data) {
+		debugf("Response file '%s' is not valid JSON yet\n", path)
+		return approval, nil
+	}
+
+	resp := &fileResponse{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	decoder.UseNumber()
+	if err := decoder.Decode(resp); err != nil {
+		return nil, validationErrorf("malformed response file %s: %w", path, err)
+	}
+
+	if resp.ID != req.ID {
+		return nil, validationErrorf("stale response file %s: it answers approval request '%s'", path, resp.ID)
+	}
+	switch strings.ToLower(resp.Decision) {
+	case "approve", "approved":
+		approval.Status = ApprovalStatusApproved
+	case "reject", "rejected":
+		approval.Status = ApprovalStatusRejected
+	default:
+		return nil, validationErrorf("malformed response file %s: decision must be approved or rejected, got '%s'", path, resp.Decision)
+	}
+	if resp.Approver == "" {
+		return nil, validationErrorf("malformed response file %s: approver missing", path)
+	}
+	respondedOn, err := time.Parse(time.RFC3339, resp.RespondedOn)
+	if err != nil {
+		return nil, validationErrorf("malformed response file %s: respondedOn must be an RFC 3339 time: %w", path, err)
+	}
+	if respondedOn.Before(req.CreatedOn.Truncate(time.Second)) {
+		return nil, validationErrorf("stale response file %s: responded on %s before the request was created on %s",
+			path, resp.RespondedOn, req.CreatedOn.Format(time.RFC3339))
+	}
+
+	schema, err := usableApprovalInputs(req.ApprovalInputs)
+	if err != nil {
+		return nil, err
+	}
+	values := map[string]string{}
+	for name, value := range resp.Inputs {
+		encoded, err := valueToString(value)
+		if err != nil {
+			return nil, validationErrorf("invalid response file %s: invalid value for input '%s': %w", path, name, err)
+		}
+		values[name] = encoded
+	}
+	inputs, err := inputsFromValues(schema, values, approval.Status == ApprovalStatusApproved)
+	if err != nil {
+		return nil, validationErrorf("invalid response file %s: %w", path, err)
+	}
+
+	approval.UserName = resp.Approver
+	approval.RespondedOn = resp.RespondedOn
+	approval.Comments = resp.Comments
+	approval.ReasonCode = resp.ReasonCode
+	approval.Inputs = inputs
+	return approval, nil
+}
+
+// UpdateStatus writes the response file for the decision of the approve and reject handlers
+func (b *fileBackend) UpdateStatus(_ context.Context, decision map[string]interface{}) error {
+	id, _ := decision["id"].(string)
+	if id == "" {
+		return fmt.Errorf("approval request ID missing")
+	}
+	if _, err := b.readRequest(id); err != nil {
+		return err
+	}
+
+	resp := &fileResponse{ID: id}
+	resp.Approver, _ = decision["userName"].(string)
+	if resp.Approver == "" {
+		resp.Approver = localUserName()
+	}
+	resp.Comments, _ = decision["comments"].(string)
+	resp.ReasonCode, _ = decision["reasonCode"].(string)
+	resp.RespondedOn, _ = decision["respondedOn"].(string)
+	if resp.RespondedOn == "" {
+		resp.RespondedOn = b.k.now().UTC().Format(time.RFC3339)
+	}
+	switch decision["status"] {
+	case "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED":
+		resp.Decision = "approved"
+	case "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED":
+		resp.Decision = "rejected"
+	default:
+		return fmt.Errorf("unexpected approval status '%s'", decision["status"])
+	}
+	if inputs, ok := decision["inputs"].([]interface{}); ok && len(inputs) > 0 {
+		resp.Inputs = map[string]interface{}{}
+		for _, input := range inputs {
+			ip := input.(map[string]interface{})
+			resp.Inputs[ip["name"].(string)] = ip["value"]
+		}
+	}
+
+	data, err := json.MarshalIndent(resp, "", "  ")
+	if err != nil {
+		return err
+	}
+	if b.k.DryRun {
+		b.k.logDryRunFile(b.responseFile(id), data)
+		return nil
+	}
+	return writeFileAtomic(b.responseFile(id), data)
+}
+
+// Cancel marks the request file as aborted or timed out, later responses are ignored
+func (b *fileBackend) Cancel(_ context.Context, id string, status string) error {
+	if id == "" {
+		return fmt.Errorf("approval request ID missing")
+	}
+	req, err := b.readRequest(id)
+	if err != nil {
+		return err
+	}
+	req.Status = status
+	return b.writeRequest(req)
+}
+
+func (b *fileBackend) readRequest(id string) (*fileRequest, error) {
+	if err := checkFileApprovalID(id); err != nil {
+		return nil, err
+	}
+	if req, ok := b.dryRunRequests[id]; ok {
+		copied := *req
+		return &copied, nil
+	}
+	path := b.requestFile(id)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("approval request %s not found in %s", id, b.dir)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req := &fileRequest{}
+	if err := json.Unmarshal(data, req); err != nil {
+		return nil, fmt.Errorf("malformed request file %s: %w", path, err)
+	}
+	return req, nil
+}
+
+func (b *fileBackend) writeRequest(req *fileRequest) error {
+	data, err := json.MarshalIndent(req, "", "  ")
+	if err != nil {
+		return err
+	}
+	if b.k.DryRun {
+		if b.dryRunRequests == nil {
+			b.dryRunRequests = map[string]*fileRequest{}
+		}
+		b.dryRunRequests[req.ID] = req
+		b.k.logDryRunFile(b.requestFile(req.ID), data)
+		return nil
+	}
+	if err := os.MkdirAll(b.dir, 0755); err != nil {
+		return err
+	}
+	return writeFileAtomic(b.requestFile(req.ID), data)
+}
+
+// writeFileAtomic replaces the file in one step, so a watcher never reads a partially written file
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write to %s: %w", path, err)
+	}
+	return nil
+}
+
+// checkFileApprovalID rejects IDs that would name a file outside the approval directory
+func checkFileApprovalID(id string) error {
+	if id == "" || id == "." || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return configErrorf("invalid approval request ID '%s'", id)
+	}
+	return nil
+}
+
+func newApprovalID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
@@ -0,0 +1,262 @@
+package manual_approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_fileBackendGet(t *testing.T) {
+	const inputs = "replicas:\n  type: number\n  required: true\nnotify:\n  type: boolean\n  default: false"
+
+	tests := []struct {
+		name     string
+		response string
+		approval *ApprovalRequest
+		err      string
+	}{
+		{
+			name:     "no response yet",
+			approval: &ApprovalRequest{Status: ApprovalStatusPending},
+		},
+		{
+			name:     "approved",
+			response: `{"id":"%s","decision":"approved","approver":"jane","comments":"lgtm","respondedOn":"2026-10-18T12:05:00Z","inputs":{"replicas":3}}`,
+			approval: &ApprovalRequest{
+				Status:      ApprovalStatusApproved,
+				UserName:    "jane",
+				RespondedOn: "2026-10-18T12:05:00Z",
+				Comments:    "lgtm",
+				Inputs: []interface{}{
+					map[string]interface{}{"name": "replicas", "value": json.Number("3"), "is_default": false},
+					map[string]interface{}{"name": "notify", "value": false, "is_default": true},
+				},
+			},
+		},
+		{
+			name:     "rejected with string input values",
+			response: `{"id":"%s","decision":"Reject","approver":"jane","respondedOn":"2026-10-18T12:05:00Z","inputs":{"replicas":"2","notify":"true"}}`,
+			approval: &ApprovalRequest{
+				Status:      ApprovalStatusRejected,
+				UserName:    "jane",
+				RespondedOn: "2026-10-18T12:05:00Z",
+				Inputs: []interface{}{
+					map[string]interface{}{"name": "replicas", "value": json.Number("2"), "is_default": false},
+					map[string]interface{}{"name": "notify", "value": true, "is_default": false},
+				},
+			},
+		},
+		{
+			name:     "partially written",
+			response: `{"id":"%s","decision":`,
+			approval: &ApprovalRequest{Status: ApprovalStatusPending},
+		},
+		{
+			name:     "empty",
+			response: " ",
+			approval: &ApprovalRequest{Status: ApprovalStatusPending},
+		},
+		{
+			name:     "wrong type",
+			response: `{"id":"%s","decision":"approved","approver":"jane","respondedOn":"2026-10-18T12:05:00Z","inputs":[3]}`,
+			err:      "malformed response file <file>: json: cannot unmarshal array into Go struct field fileResponse.inputs of type map[string]interface {}",
+		},
+		{
+			name:     "unknown field",
+			response: `{"id":"%s","decision":"approved","approver":"jane","respondedOn":"2026-10-18T12:05:00Z","approved":true}`,
+			err:      "malformed response file <file>: json: unknown field \"approved\"",
+		},
+		{
+			name:     "unknown decision",
+			response: `{"id":"%s","decision":"maybe","approver":"jane","respondedOn":"2026-10-18T12:05:00Z"}`,
+			err:      "malformed response file <file>: decision must be approved or rejected, got 'maybe'",
+		},
+		{
+			name:     "approver missing",
+			response: `{"id":"%s","decision":"approved","respondedOn":"2026-10-18T12:05:00Z"}`,
+			err:      "malformed response file <file>: approver missing",
+		},
+		{
+			name:     "invalid time",
+			response: `{"id":"%s","decision":"approved","approver":"jane","respondedOn":"yesterday"}`,
+			err:      "malformed response file <file>: respondedOn must be an RFC 3339 time: parsing time \"yesterday\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \"yesterday\" as \"2006\"",
+		},
+		{
+			name:     "response to another request",
+			response: `{"id":"other","decision":"approved","approver":"jane","respondedOn":"2026-10-18T12:05:00Z"}`,
+			err:      "stale response file <file>: it answers approval request 'other'",
+		},
+		{
+			name:     "responded before the request was created",
+			response: `{"id":"%s","decision":"approved","approver":"jane","respondedOn":"2026-10-17T12:00:00Z","inputs":{"replicas":3}}`,
+			err:      "stale response file <file>: responded on 2026-10-17T12:00:00Z before the request was created on 2026-10-18T12:00:00Z",
+		},
+		{
+			name:     "invalid input value",
+			response: `{"id":"%s","decision":"approved","approver":"jane","respondedOn":"2026-10-18T12:05:00Z","inputs":{"replicas":"many"}}`,
+			err:      "invalid response file <file>: invalid value for input 'replicas': 'many' is not a number",
+		},
+		{
+			name:     "required input missing",
+			response: `{"id":"%s","decision":"approved","approver":"jane","respondedOn":"2026-10-18T12:05:00Z"}`,
+			err:      "invalid response file <file>: input 'replicas' is required",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			c := &Config{BackendDir: dir, clock: func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }}
+			backend, err := newFileBackend(c)
+			require.NoError(t, err)
+			created, err := backend.Create(context.Background(), map[string]interface{}{"approvers": []string{"jane"}, "approvalInputs": inputs})
+			require.NoError(t, err)
+			file := backend.responseFile(created.ID)
+			if tt.response != "" {
+				response := strings.ReplaceAll(tt.response, "%s", created.ID)
+				require.NoError(t, os.WriteFile(file, []byte(response), 0644))
+			}
+
+			// Run
+			approval, err := backend.Get(context.Background(), created.ID)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, created.ID, approval.ID)
+				require.Equal(t, []Approvers{{UserName: "jane"}}, approval.Approvers)
+				require.Equal(t, tt.approval.Status, approval.Status)
+				require.Equal(t, tt.approval.UserName, approval.UserName)
+				require.Equal(t, tt.approval.RespondedOn, approval.RespondedOn)
+				require.Equal(t, tt.approval.Comments, approval.Comments)
+				require.Equal(t, tt.approval.Inputs, approval.Inputs)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, strings.ReplaceAll(tt.err, "<file>", file), err.Error())
+			}
+		})
+	}
+}
+
+func Test_fileBackendRequestFile(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	c := &Config{BackendDir: dir, clock: func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }}
+	backend, err := newFileBackend(c)
+	require.NoError(t, err)
+
+	// Run
+	created, err := backend.Create(context.Background(), map[string]interface{}{
+		"approvers":              []string{"jane", "joe"},
+		"instructions":           "Check the dashboard",
+		"approvalInputs":         "env:\n  type: choice\n  options: [staging, production]\nticket:\n  type: string\n  required: true\n  when:\n    env: production",
+		"disallowLaunchedByUser": true,
+		"notifyEligibleUsers":    false,
+	})
+	require.NoError(t, err)
+	require.NoError(t, backend.Cancel(context.Background(), created.ID, ApprovalStatusTimedOut))
+
+	// Verify
+	out, err := os.ReadFile(filepath.Join(dir, created.ID+".request.json"))
+	require.NoError(t, err)
+	require.JSONEq(t, fmt.Sprintf(`{
+		"id": "%s",
+		"status": "TIMED_OUT",
+		"approvers": ["jane", "joe"],
+		"instructions": "Check the dashboard",
+		"approvalInputs": "env:\n  type: choice\n  options: [staging, production]\nticket:\n  type: string\n  required: true\n  when:\n    env: production",
+		"inputs": [
+			{"name": "env", "type": "choice", "options": ["staging", "production"]},
+			{"name": "ticket", "type": "string", "required": true, "when": {"env": ["production"]}}
+		],
+		"disallowLaunchedByUser": true,
+		"notifyEligibleUsers": false,
+		"createdOn": "2026-10-18T12:00:00Z"
+	}`, created.ID), string(out))
+
+	approval, err := backend.Get(context.Background(), created.ID)
+	require.NoError(t, err)
+	require.Equal(t, ApprovalStatusTimedOut, approval.Status)
+
+	_, err = backend.Get(context.Background(), "missing")
+	require.EqualError(t, err, "approval request missing not found in "+dir)
+
+	_, err = backend.Get(context.Background(), "../"+created.ID)
+	require.EqualError(t, err, "invalid approval request ID '../"+created.ID+"'")
+	err = backend.UpdateStatus(context.Background(), map[string]interface{}{"id": "../../x", "status": "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED"})
+	require.EqualError(t, err, "invalid approval request ID '../../x'")
+	require.NoFileExists(t, filepath.Join(dir, "..", "..", "x.response.json"))
+}
+
+func Test_fileBackendApproveAndWait(t *testing.T) {
+	// Prepare
+	t.Setenv("USER", "jane")
+	dir := t.TempDir()
+	outputs := t.TempDir()
+	var testOutput strings.Builder
+	output := &MockStdOut{
+		MockPrintf: func(format string, a ...any) {
+			testOutput.WriteString(fmt.Sprintf(format, a...))
+		},
+		MockPrintln: func(a ...any) {
+			testOutput.WriteString(fmt.Sprintln(a...))
+		},
+	}
+	clock := func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+
+	waiting := Config{
+		BackendType:  BackendFile,
+		BackendDir:   dir,
+		Approvers:    "jane",
+		Inputs:       "replicas:\n  type: number",
+		OutputsDir:   outputs,
+		StatusFile:   filepath.Join(outputs, "status"),
+		PollInterval: time.Millisecond,
+		Output:       output,
+		clock:        clock,
+	}
+
+	// Run
+	done := make(chan error)
+	go func() {
+		done <- waiting.wait()
+	}()
+
+	var requests []string
+	require.Eventually(t, func() bool {
+		requests, _ = filepath.Glob(filepath.Join(dir, "*.request.json"))
+		return len(requests) == 1
+	}, 5*time.Second, time.Millisecond)
+	id := strings.TrimSuffix(filepath.Base(requests[0]), ".request.json")
+
+	approving := Config{
+		BackendType: BackendFile,
+		BackendDir:  dir,
+		ApprovalID:  id,
+		Comments:    "lgtm",
+		InputValues: []string{"replicas=3"},
+		Output:      &MockStdOut{MockPrintf: func(string, ...any) {}},
+		clock:       clock,
+	}
+	require.NoError(t, approving.respond(true))
+	err := <-done
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, "Waiting for approval from one of the following: jane\n"+
+		"Approved by jane on 2026-10-18T12:00:00Z with comments:\nlgtm\n"+
+		"\nInput Parameters:\n------------------\n replicas: 3 \n", testOutput.String())
+
+	requireStatusFile(t, "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"APPROVED\"}", filepath.Join(outputs, "status"))
+
+	out, err := os.ReadFile(filepath.Join(outputs, "approvalInputValues"))
+	require.NoError(t, err)
+	require.Equal(t, "{\"replicas\":3}", string(out))
+}
@@ -0,0 +1,567 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"math"
+	"math/big"
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	InputTypeString      = "string"
+	InputTypeNumber      = "number"
+	InputTypeBoolean     = "boolean"
+	InputTypeChoice      = "choice"
+	InputTypeMultiChoice = "multichoice"
+	InputTypeDateTime    = "datetime"
+	InputTypeURL         = "url"
+	InputTypeInteger     = "integer"
+	InputTypeText        = "text"
+)
+
+var inputTypes = []string{InputTypeString, InputTypeNumber, InputTypeBoolean, InputTypeChoice,
+	InputTypeMultiChoice, InputTypeDateTime, InputTypeURL, InputTypeInteger, InputTypeText}
+
+var inputAttributes = []string{"type", "description", "required", "default", "options",
+	"pattern", "minLength", "maxLength", "minimum", "maximum", "errorMessage", "when"}
+
+// textInputTypes are the input types with pattern and length constraints
+var textInputTypes = []string{InputTypeString, InputTypeText, InputTypeURL}
+
+// ApprovalInput is a single input parameter of the approvalInputs definition
+type ApprovalInput struct {
+	Name        string
+	Type        string
+	Description string
+	Required    bool
+	Default     interface{}
+	Options     []string
+
+	// Pattern is a regular expression the whole value of string, text and url inputs must match
+	Pattern string
+
+	// MinLength and MaxLength limit the number of characters of string, text and url inputs
+	MinLength *int
+	MaxLength *int
+
+	// Minimum and Maximum bound the value of number and integer inputs, empty when not set
+	Minimum json.Number
+	Maximum json.Number
+
+	// ErrorMessage replaces the message of a value that violates the constraints above
+	ErrorMessage string
+
+	// When lists the conditions on other inputs that must all be met for the input to apply. An input
+	// that does not apply is not required and left out of the outputs.
+	When []InputCondition
+
+	// Line of the input definition in the approvalInputs YAML document
+	Line int
+}
+
+// InputError describes a problem with an input of the approvalInputs definition
+type InputError struct {
+	Line    int
+	Input   string
+	Message string
+
+	// unknown is set for attributes that are not part of the definition, the platform ignores them
+	unknown bool
+}
+
+func (e *InputError) Error() string {
+	if e.Input == "" {
+		return fmt.Sprintf("line %d: %s", e.Line, e.Message)
+	}
+	return fmt.Sprintf("line %d: input '%s': %s", e.Line, e.Input, e.Message)
+}
+
+// InputErrors collects every problem found in the approvalInputs definition
+type InputErrors []*InputError
+
+func (e InputErrors) Error() string {
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return "invalid approvalInputs: " + strings.Join(messages, "; ")
+}
+
+// unknownAttributesOnly reports whether every problem is an unknown attribute, which leaves the
+// parsed inputs usable
+func (e InputErrors) unknownAttributesOnly() bool {
+	for _, err := range e {
+		if !err.unknown {
+			return false
+		}
+	}
+	return true
+}
+
+// parseApprovalInputs parses the approvalInputs YAML definition, keeping the order of the inputs
+func parseApprovalInputs(definition string) ([]ApprovalInput, error) {
+	if strings.TrimSpace(definition) == "" {
+		return nil, nil
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(definition), &doc); err != nil {
+		// definitions passed on a single line with escaped line breaks are accepted by the platform
+		escaped := !strings.Contains(definition, "\n") && strings.Contains(definition, `\n`)
+		if !escaped || yaml.Unmarshal([]byte(strings.ReplaceAll(definition, `\n`, "\n")), &doc) != nil {
+			line, message := yamlError(err)
+			return nil, InputErrors{{Line: line, Message: message}}
+		}
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, InputErrors{{Line: root.Line, Message: "expected a mapping of input names to input definitions"}}
+	}
+
+	var inputs []ApprovalInput
+	var errs InputErrors
+	seen := map[string]bool{}
+	for i := 0; i < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		if seen[key.Value] {
+			errs = append(errs, &InputError{Line: key.Line, Input: key.Value, Message: "duplicate input"})
+			continue
+		}
+		seen[key.Value] = true
+
+		input, inputErrs := parseApprovalInput(key, value)
+		errs = append(errs, inputErrs...)
+		inputs = append(inputs, input)
+	}
+	if errs.unknownAttributesOnly() {
+		errs = append(errs, checkConditions(inputs)...)
+	}
+
+	if len(errs) > 0 {
+		return inputs, errs
+	}
+	return inputs, nil
+}
+
+func parseApprovalInput(key *yaml.Node, value *yaml.Node) (ApprovalInput, InputErrors) {
+	input := ApprovalInput{Name: key.Value, Line: key.Line}
+	fail := func(node *yaml.Node, format string, a ...any) *InputError {
+		return &InputError{Line: node.Line, Input: input.Name, Message: fmt.Sprintf(format, a...)}
+	}
+
+	if strings.TrimSpace(key.Value) == "" {
+		return input, InputErrors{fail(key, "input name must not be empty")}
+	}
+	if value.Kind != yaml.MappingNode {
+		return input, InputErrors{fail(value, "expected a mapping of input attributes")}
+	}
+
+	var errs InputErrors
+	var defaultNode *yaml.Node
+	constraintNodes := map[string]*yaml.Node{}
+	for i := 0; i < len(value.Content); i += 2 {
+		attr, attrValue := value.Content[i], value.Content[i+1]
+		switch attr.Value {
+		case "type":
+			input.Type = attrValue.Value
+		case "description":
+			input.Description = attrValue.Value
+		case "required":
+			required, err := strconv.ParseBool(attrValue.Value)
+			if err != nil {
+				errs = append(errs, fail(attrValue, "required must be true or false, got '%s'", attrValue.Value))
+			}
+			input.Required = required
+		case "default":
+			defaultNode = attrValue
+		case "options":
+			if attrValue.Kind != yaml.SequenceNode {
+				errs = append(errs, fail(attrValue, "options must be a list"))
+				continue
+			}
+			for _, option := range attrValue.Content {
+				if slices.Contains(input.Options, option.Value) {
+					errs = append(errs, fail(option, "duplicate option '%s'", option.Value))
+				}
+				input.Options = append(input.Options, option.Value)
+			}
+		case "pattern":
+			if _, err := regexp.Compile(attrValue.Value); err != nil {
+				errs = append(errs, fail(attrValue, "invalid pattern: %s", err))
+			}
+			input.Pattern = attrValue.Value
+			constraintNodes[attr.Value] = attr
+		case "minLength", "maxLength":
+			length, err := strconv.Atoi(attrValue.Value)
+			if err != nil || length < 0 || attrValue.Kind != yaml.ScalarNode {
+				errs = append(errs, fail(attrValue, "%s must be a non-negative integer, got '%s'", attr.Value, attrValue.Value))
+				continue
+			}
+			if attr.Value == "minLength" {
+				input.MinLength = &length
+			} else {
+				input.MaxLength = &length
+			}
+			constraintNodes[attr.Value] = attr
+		case "minimum", "maximum":
+			// checked against the type once all attributes are read
+			constraintNodes[attr.Value] = attrValue
+		case "when":
+			conditions, whenErrs := parseWhen(attrValue, fail)
+			errs = append(errs, whenErrs...)
+			input.When = conditions
+		case "errorMessage":
+			input.ErrorMessage = attrValue.Value
+			constraintNodes[attr.Value] = attr
+		default:
+			err := fail(attr, "unknown attribute '%s', expected one of: %s", attr.Value, strings.Join(inputAttributes, ", "))
+			err.unknown = true
+			errs = append(errs, err)
+		}
+	}
+
+	switch {
+	case input.Type == "":
+		errs = append(errs, fail(key, "type is required"))
+	case !slices.Contains(inputTypes, input.Type):
+		errs = append(errs, fail(key, "unsupported type '%s', expected one of: %s", input.Type, strings.Join(inputTypes, ", ")))
+	case input.hasOptions() && len(input.Options) == 0:
+		errs = append(errs, fail(key, "options are required for %s inputs", input.Type))
+	case !input.hasOptions() && input.Options != nil:
+		errs = append(errs, fail(key, "options are only supported for choice and multichoice inputs"))
+	default:
+		errs = append(errs, input.parseConstraints(constraintNodes, fail)...)
+	}
+
+	if defaultNode != nil && errs.unknownAttributesOnly() {
+		value := defaultNode.Value
+		if defaultNode.Kind == yaml.SequenceNode {
+			var options []string
+			for _, option := range defaultNode.Content {
+				options = append(options, option.Value)
+			}
+			encoded, _ := json.Marshal(options)
+			value = string(encoded)
+		}
+		defaultValue, err := input.parseValue(value)
+		if err != nil {
+			errs = append(errs, fail(defaultNode, "invalid default: %s", err))
+		}
+		input.Default = defaultValue
+	}
+
+	return input, errs
+}
+
+// parseConstraints checks that the constraint attributes are supported by the type of the input and
+// consistent with each other
+func (input *ApprovalInput) parseConstraints(nodes map[string]*yaml.Node, fail func(*yaml.Node, string, ...any) *InputError) InputErrors {
+	var errs InputErrors
+	for _, attr := range []string{"pattern", "minLength", "maxLength"} {
+		if node := nodes[attr]; node != nil && !slices.Contains(textInputTypes, input.Type) {
+			errs = append(errs, fail(node, "%s is only supported for %s inputs", attr, strings.Join(textInputTypes, ", ")))
+		}
+	}
+	if input.MinLength != nil && input.MaxLength != nil && *input.MinLength > *input.MaxLength {
+		errs = append(errs, fail(nodes["minLength"], "minLength %d is greater than maxLength %d", *input.MinLength, *input.MaxLength))
+	}
+
+	bounds := map[string]*json.Number{"minimum": &input.Minimum, "maximum": &input.Maximum}
+	for _, attr := range []string{"minimum", "maximum"} {
+		node := nodes[attr]
+		if node == nil {
+			continue
+		}
+		var valid bool
+		switch input.Type {
+		case InputTypeInteger:
+			_, valid = new(big.Int).SetString(node.Value, 10)
+			valid = valid && node.Kind == yaml.ScalarNode
+			if !valid {
+				errs = append(errs, fail(node, "%s must be an integer, got '%s'", attr, node.Value))
+			}
+		case InputTypeNumber:
+			valid = node.Kind == yaml.ScalarNode && jsonNumber.MatchString(node.Value)
+			if !valid {
+				errs = append(errs, fail(node, "%s must be a number, got '%s'", attr, node.Value))
+			}
+		default:
+			errs = append(errs, fail(node, "%s is only supported for number and integer inputs", attr))
+		}
+		if valid {
+			*bounds[attr] = json.Number(strings.TrimPrefix(node.Value, "+"))
+		}
+	}
+	if input.Minimum != "" && input.Maximum != "" && compareNumbers(input.Minimum, input.Maximum) > 0 {
+		errs = append(errs, fail(nodes["minimum"], "minimum %s is greater than maximum %s", input.Minimum, input.Maximum))
+	}
+
+	if nodes["errorMessage"] != nil && len(nodes) == 1 {
+		errs = append(errs, fail(nodes["errorMessage"], "errorMessage requires pattern, minLength, maxLength, minimum or maximum"))
+	}
+	return errs
+}
+
+// parseValue converts the string form of a value to the type of the input and checks the
+// constraints of the input
+func (input *ApprovalInput) parseValue(value string) (interface{}, error) {
+	parsed, err := input.parseType(value)
+	if err != nil {
+		return nil, err
+	}
+	if err := input.checkConstraints(value, parsed); err != nil {
+		if input.ErrorMessage != "" {
+			return nil, errors.New(input.ErrorMessage)
+		}
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// checkConstraints checks the pattern, length and bounds of a value
+func (input *ApprovalInput) checkConstraints(value string, parsed interface{}) error {
+	if input.Pattern != "" && !regexp.MustCompile(`^(?:`+input.Pattern+`)$`).MatchString(value) {
+		return fmt.Errorf("'%s' does not match the pattern %s", value, input.Pattern)
+	}
+	length := utf8.RuneCountInString(value)
+	if input.MinLength != nil && length < *input.MinLength {
+		return fmt.Errorf("'%s' is shorter than %d characters", value, *input.MinLength)
+	}
+	if input.MaxLength != nil && length > *input.MaxLength {
+		return fmt.Errorf("'%s' is longer than %d characters", value, *input.MaxLength)
+	}
+
+	number, ok := parsed.(json.Number)
+	if !ok {
+		return nil
+	}
+	if input.Minimum != "" && compareNumbers(number, input.Minimum) < 0 {
+		return fmt.Errorf("%s is less than the minimum %s", number, input.Minimum)
+	}
+	if input.Maximum != "" && compareNumbers(number, input.Maximum) > 0 {
+		return fmt.Errorf("%s is greater than the maximum %s", number, input.Maximum)
+	}
+	return nil
+}
+
+// parseType converts the string form of a value to the type of the input
+func (input *ApprovalInput) parseType(value string) (interface{}, error) {
+	switch input.Type {
+	case InputTypeNumber:
+		number, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+			return nil, fmt.Errorf("'%s' is not a number", value)
+		}
+		if jsonNumber.MatchString(value) {
+			return json.Number(value), nil
+		}
+		return json.Number(strconv.FormatFloat(number, 'f', -1, 64)), nil
+	case InputTypeBoolean:
+		boolean, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a boolean", value)
+		}
+		return boolean, nil
+	case InputTypeChoice:
+		if !slices.Contains(input.Options, value) {
+			return nil, fmt.Errorf("'%s' is not one of the options: %s", value, strings.Join(input.Options, ", "))
+		}
+		return value, nil
+	case InputTypeMultiChoice:
+		return input.parseSelection(value)
+	case InputTypeDateTime:
+		t, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not an RFC 3339 date and time, such as 2024-05-01T18:00:00Z", value)
+		}
+		return t.Format(time.RFC3339Nano), nil
+	case InputTypeURL:
+		u, err := url.Parse(value)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("'%s' is not an absolute URL", value)
+		}
+		return value, nil
+	case InputTypeInteger:
+		if _, ok := new(big.Int).SetString(value, 10); !ok {
+			return nil, fmt.Errorf("'%s' is not an integer", value)
+		}
+		return json.Number(strings.TrimPrefix(value, "+")), nil
+	default:
+		return value, nil
+	}
+}
+
+// hasOptions reports whether the value of the input is picked from its options
+func (input *ApprovalInput) hasOptions() bool {
+	return input.Type == InputTypeChoice || input.Type == InputTypeMultiChoice
+}
+
+// parseSelection parses the options of a multichoice value, given as a JSON list or separated by
+// commas, into a list keeping the order of the options
+func (input *ApprovalInput) parseSelection(value string) ([]interface{}, error) {
+	var selected []string
+	if strings.HasPrefix(strings.TrimSpace(value), "[") {
+		if err := json.Unmarshal([]byte(value), &selected); err != nil {
+			return nil, fmt.Errorf("'%s' is not a list of options", value)
+		}
+	} else if strings.TrimSpace(value) != "" {
+		for _, option := range strings.Split(value, ",") {
+			selected = append(selected, strings.TrimSpace(option))
+		}
+	}
+
+	for i, option := range selected {
+		if !slices.Contains(input.Options, option) {
+			return nil, fmt.Errorf("'%s' is not one of the options: %s", option, strings.Join(input.Options, ", "))
+		}
+		if slices.Contains(selected[:i], option) {
+			return nil, fmt.Errorf("option '%s' is selected more than once", option)
+		}
+	}
+
+	values := []interface{}{}
+	for _, option := range input.Options {
+		if slices.Contains(selected, option) {
+			values = append(values, option)
+		}
+	}
+	return values, nil
+}
+
+// compareNumbers compares two valid JSON numbers exactly
+func compareNumbers(a json.Number, b json.Number) int {
+	x, _ := new(big.Rat).SetString(a.String())
+	y, _ := new(big.Rat).SetString(b.String())
+	return x.Cmp(y)
+}
+
+// yamlError splits a YAML syntax error into the line number and the message
+func yamlError(err error) (int, string) {
+	var line int
+	if _, scanErr := fmt.Sscanf(err.Error(), "yaml: line %d:", &line); scanErr != nil {
+		return 0, err.Error()
+	}
+	_, message, _ := strings.Cut(err.Error(), ": line "+strconv.Itoa(line)+": ")
+	return line, message
+}
+
+// checkApprovalInputs parses the approvalInputs definition. Unknown attributes are ignored like the
+// platform does and returned as warnings, every other problem is an error.
+func checkApprovalInputs(definition string) ([]ApprovalInput, InputErrors, error) {
+	schema, err := parseApprovalInputs(definition)
+	var inputErrs InputErrors
+	if errors.As(err, &inputErrs) && inputErrs.unknownAttributesOnly() {
+		return schema, inputErrs, nil
+	}
+	return schema, nil, err
+}
+
+// usableApprovalInputs parses the approvalInputs definition for the local backends, ignoring
+// unknown attributes
+func usableApprovalInputs(definition string) ([]ApprovalInput, error) {
+	schema, _, err := checkApprovalInputs(definition)
+	return schema, err
+}
+
+// checkInputValues checks the input values of an approver response against the approvalInputs
+// definition and converts every value to the type of its input. Inputs whose when conditions are not
+// met are left out, and required inputs that apply must have a value when the request is approved.
+// Without a definition the values are passed on as they are.
+func (k *Config) checkInputValues(inputs []interface{}, approved bool) ([]interface{}, error) {
+	schema, _, err := checkApprovalInputs(valueOrEnv(k.Inputs, "INPUTS"))
+	if err != nil {
+		return nil, err
+	}
+	k.inputSchema = schema
+	if schema == nil {
+		return inputs, nil
+	}
+
+	values := map[string]interface{}{}
+	for _, value := range inputs {
+		ip, ok := value.(map[string]interface{})
+		if !ok {
+			return nil, validationErrorf("invalid input %v", value)
+		}
+		name, _ := ip["name"].(string)
+		input := k.schemaInput(name)
+		if input == nil {
+			return nil, validationErrorf("unknown input '%s'", name)
+		}
+		if ip["value"] == nil {
+			continue
+		}
+		encoded, err := valueToString(ip["value"])
+		if err != nil {
+			return nil, validationErrorf("invalid value for input '%s': %w", name, err)
+		}
+		parsed, err := input.parseValue(encoded)
+		if err != nil {
+			return nil, validationErrorf("invalid value for input '%s': %w", name, err)
+		}
+		ip["value"] = parsed
+		values[name] = parsed
+	}
+
+	applicable := applicableInputs(schema, values)
+	checked := []interface{}{}
+	for _, value := range inputs {
+		if applicable[value.(map[string]interface{})["name"].(string)] {
+			checked = append(checked, value)
+		}
+	}
+	if approved {
+		for _, input := range schema {
+			if input.Required && applicable[input.Name] && values[input.Name] == nil {
+				return nil, validationErrorf("input '%s' is required", input.Name)
+			}
+		}
+	}
+	return checked, nil
+}
+
+// schemaInput returns the input of the checked approvalInputs definition with the given name
+func (k *Config) schemaInput(name string) *ApprovalInput {
+	for i := range k.inputSchema {
+		if k.inputSchema[i].Name == name {
+			return &k.inputSchema[i]
+		}
+	}
+	return nil
+}
+
+// displayInputValue formats the string form of an input value for the log: the options of
+// multichoice inputs separated by commas and URLs as links in HTML output
+func (k *Config) displayInputValue(name string, value string) string {
+	input := k.schemaInput(name)
+	if input == nil {
+		return value
+	}
+	switch input.Type {
+	case InputTypeMultiChoice:
+		var options []string
+		if err := json.Unmarshal([]byte(value), &options); err == nil {
+			return strings.Join(options, ", ")
+		}
+	case InputTypeURL:
+		if k.outputMode() == OutputModeHTML {
+			return fmt.Sprintf(`<a href="%s">%s</a>`, html.EscapeString(value), html.EscapeString(value))
+		}
+	}
+	return value
+}
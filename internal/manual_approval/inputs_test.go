@@ -0,0 +1,402 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseApprovalInputs(t *testing.T) {
+	tests := []struct {
+		name       string
+		definition string
+		inputs     []ApprovalInput
+		err        string
+	}{
+		{
+			name:       "empty",
+			definition: "",
+			inputs:     nil,
+		},
+		{
+			name:       "all types",
+			definition: "in1:\n  type: string\n  required: true\n  description: One of the required approver inputs\nin2:\n  type: number\n  default: 9.5\nin3:\n  type: choice\n  options:\n    - op1\n    - op2\nin4:\n  type: boolean\n  default: true",
+			inputs: []ApprovalInput{
+				{Name: "in1", Type: "string", Required: true, Description: "One of the required approver inputs", Line: 1},
+				{Name: "in2", Type: "number", Default: json.Number("9.5"), Line: 5},
+				{Name: "in3", Type: "choice", Options: []string{"op1", "op2"}, Line: 8},
+				{Name: "in4", Type: "boolean", Default: true, Line: 13},
+			},
+		},
+		{
+			name:       "new types",
+			definition: "targets:\n  type: multichoice\n  options: [eu, us, ap]\n  default: [us, eu]\nslot:\n  type: datetime\nrunbook:\n  type: url\nreplicas:\n  type: integer\n  minimum: 1\n  maximum: 10\n  default: 3\nnotes:\n  type: text",
+			inputs: []ApprovalInput{
+				{Name: "targets", Type: "multichoice", Options: []string{"eu", "us", "ap"}, Default: []interface{}{"eu", "us"}, Line: 1},
+				{Name: "slot", Type: "datetime", Line: 5},
+				{Name: "runbook", Type: "url", Line: 7},
+				{Name: "replicas", Type: "integer", Minimum: "1", Maximum: "10", Default: json.Number("3"), Line: 9},
+				{Name: "notes", Type: "text", Line: 14},
+			},
+		},
+		{
+			name:       "multichoice without options",
+			definition: "in1:\n  type: multichoice",
+			err:        "invalid approvalInputs: line 1: input 'in1': options are required for multichoice inputs",
+		},
+		{
+			name:       "constraints",
+			definition: "ticket:\n  type: string\n  pattern: CHG\\d{7}\n  errorMessage: Enter a change ticket such as CHG0001234\nreason:\n  type: text\n  minLength: 10\n  maxLength: 200\nratio:\n  type: number\n  minimum: -0.5\n  maximum: 1e3\n  default: 0.25",
+			inputs: []ApprovalInput{
+				{Name: "ticket", Type: "string", Pattern: `CHG\d{7}`, ErrorMessage: "Enter a change ticket such as CHG0001234", Line: 1},
+				{Name: "reason", Type: "text", MinLength: intPtr(10), MaxLength: intPtr(200), Line: 5},
+				{Name: "ratio", Type: "number", Minimum: "-0.5", Maximum: "1e3", Default: json.Number("0.25"), Line: 9},
+			},
+		},
+		{
+			name:       "bounds on a string",
+			definition: "in1:\n  type: string\n  minimum: 1",
+			err:        "invalid approvalInputs: line 3: input 'in1': minimum is only supported for number and integer inputs",
+		},
+		{
+			name:       "inconsistent constraints",
+			definition: "in1:\n  type: integer\n  pattern: '[0-9]+'\n  minLength: 5\n  maxLength: 2\nin2:\n  type: number\n  minimum: 0x10\nin3:\n  type: string\n  pattern: '(CHG'\nin4:\n  type: string\n  errorMessage: Wrong\nin5:\n  type: string\n  minLength: -1",
+			err: "invalid approvalInputs: line 3: input 'in1': pattern is only supported for string, text, url inputs; " +
+				"line 4: input 'in1': minLength is only supported for string, text, url inputs; " +
+				"line 5: input 'in1': maxLength is only supported for string, text, url inputs; " +
+				"line 4: input 'in1': minLength 5 is greater than maxLength 2; " +
+				"line 8: input 'in2': minimum must be a number, got '0x10'; " +
+				"line 11: input 'in3': invalid pattern: error parsing regexp: missing closing ): `(CHG`; " +
+				"line 14: input 'in4': errorMessage requires pattern, minLength, maxLength, minimum or maximum; " +
+				"line 17: input 'in5': minLength must be a non-negative integer, got '-1'",
+		},
+		{
+			name:       "invalid bounds",
+			definition: "in1:\n  type: integer\n  minimum: 1.5\n  maximum: [1]",
+			err:        "invalid approvalInputs: line 3: input 'in1': minimum must be an integer, got '1.5'; line 4: input 'in1': maximum must be an integer, got ''",
+		},
+		{
+			name:       "minimum above maximum",
+			definition: "in1:\n  type: integer\n  minimum: 10\n  maximum: 1",
+			err:        "invalid approvalInputs: line 3: input 'in1': minimum 10 is greater than maximum 1",
+		},
+		{
+			name:       "default out of bounds",
+			definition: "in1:\n  type: integer\n  maximum: 5\n  default: 6",
+			err:        "invalid approvalInputs: line 4: input 'in1': invalid default: 6 is greater than the maximum 5",
+		},
+		{
+			name:       "not a mapping",
+			definition: "- in1",
+			err:        "invalid approvalInputs: line 1: expected a mapping of input names to input definitions",
+		},
+		{
+			name:       "syntax error",
+			definition: "in1:\n  type: string\n type: number",
+			err:        "invalid approvalInputs: line 2: did not find expected key",
+		},
+		{
+			name:       "missing type",
+			definition: "in1:\n  description: no type",
+			err:        "invalid approvalInputs: line 1: input 'in1': type is required",
+		},
+		{
+			name:       "unknown type and attribute",
+			definition: "in1:\n  type: textarea\n  requird: true",
+			err:        "invalid approvalInputs: line 3: input 'in1': unknown attribute 'requird', expected one of: type, description, required, default, options, pattern, minLength, maxLength, minimum, maximum, errorMessage, when; line 1: input 'in1': unsupported type 'textarea', expected one of: string, number, boolean, choice, multichoice, datetime, url, integer, text",
+		},
+		{
+			name:       "choice without options",
+			definition: "in1:\n  type: choice",
+			err:        "invalid approvalInputs: line 1: input 'in1': options are required for choice inputs",
+		},
+		{
+			name:       "options on a string",
+			definition: "in1:\n  type: string\n  options: [a]",
+			err:        "invalid approvalInputs: line 1: input 'in1': options are only supported for choice and multichoice inputs",
+		},
+		{
+			name:       "default not in options",
+			definition: "in1:\n  type: choice\n  options: [a, b]\n  default: c",
+			err:        "invalid approvalInputs: line 4: input 'in1': invalid default: 'c' is not one of the options: a, b",
+		},
+		{
+			name:       "invalid boolean default",
+			definition: "in1:\n  type: boolean\n  default: maybe",
+			err:        "invalid approvalInputs: line 3: input 'in1': invalid default: 'maybe' is not a boolean",
+		},
+		{
+			name:       "duplicate input",
+			definition: "in1:\n  type: string\nin1:\n  type: number",
+			err:        "invalid approvalInputs: line 3: input 'in1': duplicate input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			inputs, err := parseApprovalInputs(tt.definition)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tt.inputs, inputs)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func Test_parseValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		input ApprovalInput
+		value string
+		want  interface{}
+		err   string
+	}{
+		{name: "number", input: ApprovalInput{Type: InputTypeNumber}, value: "12345678901234567890", want: json.Number("12345678901234567890")},
+		{name: "number not in JSON form", input: ApprovalInput{Type: InputTypeNumber}, value: "+.5", want: json.Number("0.5")},
+		{name: "number NaN", input: ApprovalInput{Type: InputTypeNumber}, value: "NaN", err: "'NaN' is not a number"},
+		{name: "multichoice list", input: ApprovalInput{Type: InputTypeMultiChoice, Options: []string{"eu", "us", "ap"}}, value: `["ap","eu"]`, want: []interface{}{"eu", "ap"}},
+		{name: "multichoice comma separated", input: ApprovalInput{Type: InputTypeMultiChoice, Options: []string{"eu", "us", "ap"}}, value: "us, eu", want: []interface{}{"eu", "us"}},
+		{name: "multichoice nothing selected", input: ApprovalInput{Type: InputTypeMultiChoice, Options: []string{"eu"}}, value: "", want: []interface{}{}},
+		{name: "multichoice unknown option", input: ApprovalInput{Type: InputTypeMultiChoice, Options: []string{"eu", "us"}}, value: "eu,mars", err: "'mars' is not one of the options: eu, us"},
+		{name: "multichoice duplicate option", input: ApprovalInput{Type: InputTypeMultiChoice, Options: []string{"eu", "us"}}, value: `["eu","eu"]`, err: "option 'eu' is selected more than once"},
+		{name: "multichoice not a list of strings", input: ApprovalInput{Type: InputTypeMultiChoice, Options: []string{"eu"}}, value: `[1]`, err: "'[1]' is not a list of options"},
+		{name: "datetime", input: ApprovalInput{Type: InputTypeDateTime}, value: "2024-05-01T18:00:00+02:00", want: "2024-05-01T18:00:00+02:00"},
+		{name: "datetime with fraction", input: ApprovalInput{Type: InputTypeDateTime}, value: "2024-05-01T18:00:00.500Z", want: "2024-05-01T18:00:00.5Z"},
+		{name: "datetime without zone", input: ApprovalInput{Type: InputTypeDateTime}, value: "2024-05-01 18:00", err: "'2024-05-01 18:00' is not an RFC 3339 date and time, such as 2024-05-01T18:00:00Z"},
+		{name: "url", input: ApprovalInput{Type: InputTypeURL}, value: "https://example.com/runbook?step=2", want: "https://example.com/runbook?step=2"},
+		{name: "relative url", input: ApprovalInput{Type: InputTypeURL}, value: "/runbook", err: "'/runbook' is not an absolute URL"},
+		{name: "integer", input: ApprovalInput{Type: InputTypeInteger}, value: "+18446744073709551616", want: json.Number("18446744073709551616")},
+		{name: "integer with fraction", input: ApprovalInput{Type: InputTypeInteger}, value: "1.0", err: "'1.0' is not an integer"},
+		{name: "integer below minimum", input: ApprovalInput{Type: InputTypeInteger, Minimum: "-5"}, value: "-6", err: "-6 is less than the minimum -5"},
+		{name: "integer at maximum", input: ApprovalInput{Type: InputTypeInteger, Minimum: "-5", Maximum: "5"}, value: "5", want: json.Number("5")},
+		{name: "pattern", input: ApprovalInput{Type: InputTypeString, Pattern: `CHG\d{7}`}, value: "CHG0001234", want: "CHG0001234"},
+		{name: "pattern matches the whole value", input: ApprovalInput{Type: InputTypeString, Pattern: `CHG\d{7}`}, value: "CHG00012345", err: "'CHG00012345' does not match the pattern CHG\\d{7}"},
+		{name: "pattern with alternatives", input: ApprovalInput{Type: InputTypeString, Pattern: `a|b`}, value: "ab", err: "'ab' does not match the pattern a|b"},
+		{name: "custom error message", input: ApprovalInput{Type: InputTypeString, Pattern: `CHG\d{7}`, ErrorMessage: "Enter a change ticket"}, value: "INC1", err: "Enter a change ticket"},
+		{name: "too short", input: ApprovalInput{Type: InputTypeText, MinLength: intPtr(3)}, value: "äö", err: "'äö' is shorter than 3 characters"},
+		{name: "too long", input: ApprovalInput{Type: InputTypeURL, MaxLength: intPtr(10)}, value: "https://example.com", err: "'https://example.com' is longer than 10 characters"},
+		{name: "length in characters", input: ApprovalInput{Type: InputTypeString, MinLength: intPtr(2), MaxLength: intPtr(2)}, value: "äö", want: "äö"},
+		{name: "number above maximum", input: ApprovalInput{Type: InputTypeNumber, Maximum: "100"}, value: "500", err: "500 is greater than the maximum 100"},
+		{name: "number above decimal maximum", input: ApprovalInput{Type: InputTypeNumber, Maximum: "0.3"}, value: "0.30000000000000001", err: "0.30000000000000001 is greater than the maximum 0.3"},
+		{name: "number range with custom message", input: ApprovalInput{Type: InputTypeNumber, Minimum: "1", Maximum: "10", ErrorMessage: "Between 1 and 10 replicas"}, value: "0", err: "Between 1 and 10 replicas"},
+		{name: "type error keeps its message", input: ApprovalInput{Type: InputTypeNumber, Maximum: "10", ErrorMessage: "Between 1 and 10 replicas"}, value: "many", err: "'many' is not a number"},
+		{name: "text", input: ApprovalInput{Type: InputTypeText}, value: "line 1\nline 2", want: "line 1\nline 2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.input.parseValue(tt.value)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_callbackChecksInputValues(t *testing.T) {
+	const schema = "targets:\n  type: multichoice\n  options: [eu, us, ap]\nslot:\n  type: datetime\nrunbook:\n  type: url\nreplicas:\n  type: integer\n  minimum: 1\n  maximum: 10\nnotes:\n  type: text\nticket:\n  type: string\n  pattern: CHG\\d{7}\n  errorMessage: Enter a change ticket such as CHG0001234"
+
+	tests := []struct {
+		name       string
+		definition string
+		inputs     string
+		outputMode string
+		output     string
+		outputs    string
+		posted     []interface{}
+		err        string
+	}{
+		{
+			name:       "valid values",
+			inputs:     `[{"name":"targets","value":["ap","eu"]},{"name":"slot","value":"2024-05-01T18:00:00Z"},{"name":"runbook","value":"https://example.com/runbook"},{"name":"replicas","value":"3"},{"name":"notes","value":"line 1\nline 2"}]`,
+			outputMode: OutputModePlain,
+			output: "Approved by jane on 2024-05-01T12:00:00Z with comments:\nlgtm\n\nInput Parameters:\n------------------\n" +
+				" targets  : eu, ap\n slot     : 2024-05-01T18:00:00Z\n runbook  : https://example.com/runbook\n replicas : 3\n notes    : line 1\n            line 2\n",
+			outputs: `{"notes":"line 1\nline 2","replicas":3,"runbook":"https://example.com/runbook","slot":"2024-05-01T18:00:00Z","targets":["eu","ap"]}`,
+			posted: []interface{}{
+				map[string]interface{}{"name": "targets", "value": `["eu","ap"]`},
+				map[string]interface{}{"name": "slot", "value": "2024-05-01T18:00:00Z"},
+				map[string]interface{}{"name": "runbook", "value": "https://example.com/runbook"},
+				map[string]interface{}{"name": "replicas", "value": "3"},
+				map[string]interface{}{"name": "notes", "value": "line 1\nline 2"},
+			},
+		},
+		{
+			name:       "URL as link in HTML",
+			inputs:     `[{"name":"runbook","value":"https://example.com/?a=1&b=2"}]`,
+			outputMode: OutputModeHTML,
+			output: "Approved by jane on 2024-05-01T12:00:00Z with comments:\nlgtm\n\nInput Parameters:\n------------------\n" +
+				` runbook: <a href="https://example.com/?a=1&amp;b=2">https://example.com/?a=1&amp;b=2</a> ` + "\n",
+			outputs: `{"runbook":"https://example.com/?a=1\u0026b=2"}`,
+			posted:  []interface{}{map[string]interface{}{"name": "runbook", "value": "https://example.com/?a=1&b=2"}},
+		},
+		{
+			name:   "out of bounds",
+			inputs: `[{"name":"replicas","value":11}]`,
+			err:    "invalid value for input 'replicas': 11 is greater than the maximum 10",
+		},
+		{
+			name:   "custom error message",
+			inputs: `[{"name":"ticket","value":"INC0001234"}]`,
+			err:    "invalid value for input 'ticket': Enter a change ticket such as CHG0001234",
+		},
+		{
+			name:   "unknown input",
+			inputs: `[{"name":"region","value":"eu"}]`,
+			err:    "unknown input 'region'",
+		},
+		{
+			name:       "out of range with an escaped definition",
+			definition: `replicas:\n  type: integer\n  maximum: 10`,
+			inputs:     `[{"name":"replicas","value":"20"}]`,
+			err:        "invalid value for input 'replicas': 20 is greater than the maximum 10",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			definition := tt.definition
+			if definition == "" {
+				definition = schema
+			}
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			var output strings.Builder
+			c := Config{
+				Handler:    "callback",
+				Inputs:     definition,
+				OutputMode: tt.outputMode,
+				OutputsDir: dir,
+				StatusFile: filepath.Join(dir, "status"),
+				Backend:    backend,
+				Payload:    `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"lgtm","respondedOn":"2024-05-01T12:00:00Z","userName":"jane","inputs":` + tt.inputs + `}`,
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						output.WriteString(fmt.Sprintf(format, a...))
+					},
+				},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Equal(t, ExitValidation, ExitCode(err))
+				require.Empty(t, backend.decisions)
+				requireStatusFile(t, fmt.Sprintf(`{"message":"Invalid approval input values: '%s'","status":"FAILED"}`, tt.err), c.StatusFile)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.output, output.String())
+			require.Equal(t, tt.posted, backend.decisions[0]["inputs"])
+			out, err := os.ReadFile(filepath.Join(dir, "approvalInputValues"))
+			require.NoError(t, err)
+			require.Equal(t, tt.outputs, string(out))
+		})
+	}
+}
+
+func Test_initChecksApprovalInputs(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs string
+		output string
+		err    string
+	}{
+		{
+			name:   "malformed constraint",
+			inputs: "replicas:\n  type: integer\n  minimum: ten",
+			err:    "invalid approvalInputs: line 3: input 'replicas': minimum must be an integer, got 'ten'",
+		},
+		{
+			name:   "unsupported type",
+			inputs: "notes:\n  type: textarea",
+			err:    "invalid approvalInputs: line 1: input 'notes': unsupported type 'textarea', expected one of: string, number, boolean, choice, multichoice, datetime, url, integer, text",
+		},
+		{
+			name:   "invalid default",
+			inputs: "replicas:\n  type: integer\n  default: many",
+			err:    "invalid approvalInputs: line 3: input 'replicas': invalid default: 'many' is not an integer",
+		},
+		{
+			name:   "condition on an unknown input",
+			inputs: "reason:\n  type: string\n  when: {action: rollback}",
+			err:    "invalid approvalInputs: line 3: input 'reason': when: unknown input 'action'",
+		},
+		{
+			name:   "circular conditions",
+			inputs: "a:\n  type: string\n  when: {b: x}\nb:\n  type: string\n  when: {a: x}",
+			err:    "invalid approvalInputs: line 1: input 'a': when: circular reference a -> b -> a",
+		},
+		{
+			name:   "unknown attribute",
+			inputs: "replicas:\n  type: integer\n  placeholder: 3",
+			output: "WARNING: approvalInputs line 3: input 'replicas': unknown attribute 'placeholder', expected one of: type, description, required, default, options, pattern, minLength, maxLength, minimum, maximum, errorMessage, when; the attribute is ignored\n" +
+				"Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:   "escaped line breaks",
+			inputs: "replicas:\\n  type: integer\\n  minimum: 1",
+			output: "Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:   "escaped line breaks with an invalid constraint",
+			inputs: "replicas:\\n  type: integer\\n  minimum: one",
+			err:    "invalid approvalInputs: line 3: input 'replicas': minimum must be an integer, got 'one'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			var output strings.Builder
+			c := Config{
+				Handler:    "init",
+				Inputs:     tt.inputs,
+				StatusFile: filepath.Join(dir, "status"),
+				Backend:    backend,
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						output.WriteString(fmt.Sprintf(format, a...))
+					},
+				},
+			}
+
+			// Run
+			err := c.init()
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				require.Equal(t, ExitValidation, ExitCode(err))
+				require.Empty(t, backend.created)
+				status, err := json.Marshal(map[string]string{"message": fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", tt.err), "status": "FAILED"})
+				require.NoError(t, err)
+				requireStatusFile(t, string(status), c.StatusFile)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.output, output.String())
+			require.Equal(t, tt.inputs, backend.created[0]["approvalInputs"])
+		})
+	}
+}
+
+func intPtr(i int) *int {
+	return &i
+}
@@ -0,0 +1,111 @@
+package manual_approval
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// inputOutputPrefix is prepended to the name of every per-input output
+const inputOutputPrefix = "input_"
+
+// writeInputOutputs writes every approval input value to its own output when INPUT_OUTPUTS is true.
+// Nothing is written when two input names map to the same output.
+func (k *Config) writeInputOutputs(values map[string]interface{}) error {
+	write, err := k.inputOutputsEnabled()
+	if err != nil || !write {
+		return err
+	}
+
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	outputs, err := inputOutputNames(names)
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		value, err := valueToString(values[name])
+		if err != nil {
+			return err
+		}
+		if err := k.writeAsOutput(outputs[name], []byte(value)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkInputOutputNames fails when INPUT_OUTPUTS is true and two inputs of the approvalInputs
+// definition would be written to the same output, so the job fails before a decision is recorded.
+// Without a definition the names of the values are checked.
+func (k *Config) checkInputOutputNames(schema []ApprovalInput, values map[string]interface{}) error {
+	write, err := k.inputOutputsEnabled()
+	if err != nil || !write {
+		return err
+	}
+
+	var names []string
+	for _, input := range schema {
+		names = append(names, input.Name)
+	}
+	if schema == nil {
+		for name := range values {
+			names = append(names, name)
+		}
+	}
+	_, err = inputOutputNames(names)
+	return err
+}
+
+// inputOutputsEnabled reports whether INPUT_OUTPUTS asks for an output per approval input
+func (k *Config) inputOutputsEnabled() (bool, error) {
+	enabled := valueOrEnv(k.InputOutputs, "INPUT_OUTPUTS")
+	if enabled == "" {
+		enabled = "false"
+	}
+	write, err := strconv.ParseBool(enabled)
+	if err != nil {
+		return false, configErrorf("invalid INPUT_OUTPUTS value '%s': %w", enabled, err)
+	}
+	return write, nil
+}
+
+// inputOutputNames maps every input name to its output name. The names are compared case
+// insensitively because outputs are files and some file systems ignore case.
+func inputOutputNames(names []string) (map[string]string, error) {
+	names = slices.Clone(names)
+	sort.Strings(names)
+
+	outputs := make(map[string]string, len(names))
+	taken := make(map[string]string, len(names))
+	for _, name := range names {
+		output := inputOutputName(name)
+		key := strings.ToLower(output)
+		if other, ok := taken[key]; ok {
+			return nil, validationErrorf("inputs '%s' and '%s' would both be written to output '%s'", other, name, output)
+		}
+		taken[key] = name
+		outputs[name] = output
+	}
+	return outputs, nil
+}
+
+// inputOutputName is the output name of an input: input_ followed by the input name with every
+// character other than ASCII letters, digits and underscores replaced by an underscore
+func inputOutputName(name string) string {
+	var b strings.Builder
+	b.WriteString(inputOutputPrefix)
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '_':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
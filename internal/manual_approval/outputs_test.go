@@ -0,0 +1,203 @@
+package manual_approval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_writeInputOutputs(t *testing.T) {
+	tests := []struct {
+		name         string
+		inputOutputs string
+		values       map[string]interface{}
+		outputs      map[string]string
+		err          string
+	}{
+		{
+			name:         "disabled",
+			inputOutputs: "false",
+			values:       map[string]interface{}{"replicas": float64(3)},
+			outputs:      map[string]string{},
+		},
+		{
+			name:         "types",
+			inputOutputs: "true",
+			values: map[string]interface{}{
+				"string-value": "a b",
+				"retry.count":  float64(3),
+				"ratio":        1.5,
+				"large":        float64(1e21),
+				"notify":       false,
+				"Env_1":        "prod",
+				"tags":         []interface{}{"a", "b"},
+				"none":         nil,
+			},
+			outputs: map[string]string{
+				"input_string_value": "a b",
+				"input_retry_count":  "3",
+				"input_ratio":        "1.5",
+				"input_large":        "1000000000000000000000",
+				"input_notify":       "false",
+				"input_Env_1":        "prod",
+				"input_tags":         `["a","b"]`,
+				"input_none":         "",
+			},
+		},
+		{
+			name:         "collision",
+			inputOutputs: "true",
+			values:       map[string]interface{}{"retry-count": float64(3), "retry.count": float64(4)},
+			outputs:      map[string]string{},
+			err:          "inputs 'retry-count' and 'retry.count' would both be written to output 'input_retry_count'",
+		},
+		{
+			name:         "collision ignoring case",
+			inputOutputs: "true",
+			values:       map[string]interface{}{"Env": "prod", "env": "staging"},
+			outputs:      map[string]string{},
+			err:          "inputs 'Env' and 'env' would both be written to output 'input_env'",
+		},
+		{
+			name:         "invalid setting",
+			inputOutputs: "yes please",
+			values:       map[string]interface{}{"replicas": float64(3)},
+			outputs:      map[string]string{},
+			err:          "invalid INPUT_OUTPUTS value 'yes please': strconv.ParseBool: parsing \"yes please\": invalid syntax",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			c := Config{OutputsDir: dir, InputOutputs: tt.inputOutputs}
+
+			// Run
+			err := c.writeInputOutputs(tt.values)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			var names []string
+			for _, entry := range entries {
+				names = append(names, entry.Name())
+			}
+			expected := make([]string, 0, len(tt.outputs))
+			for name, value := range tt.outputs {
+				expected = append(expected, name)
+				out, err := os.ReadFile(filepath.Join(dir, name))
+				require.NoError(t, err)
+				require.Equal(t, value, string(out))
+			}
+			require.ElementsMatch(t, expected, names)
+		})
+	}
+}
+
+func Test_callbackWritesInputOutputs(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	c := Config{
+		Handler:      "callback",
+		InputOutputs: "true",
+		OutputsDir:   dir,
+		StatusFile:   filepath.Join(dir, "status"),
+		Backend:      &fakeBackend{},
+		Payload:      `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"lgtm","respondedOn":"2026-10-18T12:30:00Z","userName":"jane","inputs":[{"name":"retry-count","value":3},{"name":"env","value":"prod"}]}`,
+		Output: &MockStdOut{
+			MockPrintf:  func(format string, a ...any) {},
+			MockPrintln: func(a ...any) {},
+		},
+	}
+
+	// Run
+	err := c.callback()
+
+	// Verify
+	require.NoError(t, err)
+	for name, value := range map[string]string{
+		"input_retry_count":   "3",
+		"input_env":           "prod",
+		"approvalInputValues": `{"env":"prod","retry-count":3}`,
+		"comments":            "lgtm",
+	} {
+		out, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		require.Equal(t, value, string(out))
+	}
+}
+
+func Test_initChecksInputOutputNames(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	backend := &fakeBackend{}
+	c := Config{
+		Handler:      "init",
+		Inputs:       "retry-count:\n  type: integer\nretry.count:\n  type: integer",
+		InputOutputs: "true",
+		StatusFile:   filepath.Join(dir, "status"),
+		Backend:      backend,
+		Output: &MockStdOut{
+			MockPrintf: func(format string, a ...any) {},
+		},
+	}
+
+	// Run
+	err := c.init()
+
+	// Verify
+	require.EqualError(t, err, "inputs 'retry-count' and 'retry.count' would both be written to output 'input_retry_count'")
+	require.Equal(t, ExitValidation, ExitCode(err))
+	require.Empty(t, backend.created)
+	requireStatusFile(t, `{"message":"Failed to initialize workflow manual approval request: 'inputs 'retry-count' and 'retry.count' would both be written to output 'input_retry_count''","status":"FAILED"}`, c.StatusFile)
+}
+
+func Test_callbackChecksInputOutputNames(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs string
+	}{
+		{
+			name:   "definition",
+			inputs: "Env:\n  type: string\nenv:\n  type: string",
+		},
+		{
+			name: "values without a definition",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			c := Config{
+				Handler:      "callback",
+				Inputs:       tt.inputs,
+				InputOutputs: "true",
+				OutputsDir:   dir,
+				StatusFile:   filepath.Join(dir, "status"),
+				Backend:      backend,
+				Payload:      `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"lgtm","respondedOn":"2026-10-18T12:30:00Z","userName":"jane","inputs":[{"name":"Env","value":"prod"},{"name":"env","value":"staging"}]}`,
+				Output: &MockStdOut{
+					MockPrintf:  func(format string, a ...any) {},
+					MockPrintln: func(a ...any) {},
+				},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			require.EqualError(t, err, "inputs 'Env' and 'env' would both be written to output 'input_env'")
+			require.Empty(t, backend.decisions)
+			requireStatusFile(t, `{"message":"Invalid approval input values: 'inputs 'Env' and 'env' would both be written to output 'input_env''","status":"FAILED"}`, c.StatusFile)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package manual_approval
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// rejectionPolicy is the explanation a rejection has to give
+type rejectionPolicy struct {
+	requireComment   bool
+	minCommentLength int
+	reasons          []string
+}
+
+// rejectionPolicy reads the policy from the configuration, falling back to the
+// REQUIRE_COMMENT_ON_REJECT, MIN_COMMENT_LENGTH and REJECTION_REASONS environment variables
+func (k *Config) rejectionPolicy() (*rejectionPolicy, error) {
+	policy := &rejectionPolicy{}
+
+	if value := valueOrEnv(k.RequireCommentOnReject, "REQUIRE_COMMENT_ON_REJECT"); value != "" {
+		required, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, configErrorf("invalid REQUIRE_COMMENT_ON_REJECT value '%s': %w", value, err)
+		}
+		policy.requireComment = required
+	}
+
+	if value := valueOrEnv(k.MinCommentLength, "MIN_COMMENT_LENGTH"); value != "" {
+		length, err := parseMinCommentLength(value)
+		if err != nil {
+			return nil, configErrorf("invalid MIN_COMMENT_LENGTH value '%s': %w", value, err)
+		}
+		policy.minCommentLength = length
+	}
+
+	if value := valueOrEnv(k.RejectionReasons, "REJECTION_REASONS"); value != "" {
+		reasons, err := parseRejectionReasons(value)
+		if err != nil {
+			return nil, configErrorf("invalid REJECTION_REASONS value '%s': %w", value, err)
+		}
+		policy.reasons = reasons
+	}
+
+	return policy, nil
+}
+
+// addTo adds the policy to the body of an approval request, so the platform can ask for the
+// comment and the reason code before the rejection is sent
+func (p *rejectionPolicy) addTo(body map[string]interface{}) {
+	if p.requireComment {
+		body["requireCommentOnReject"] = true
+	}
+	if p.minCommentLength > 0 {
+		body["minCommentLength"] = p.minCommentLength
+	}
+	if len(p.reasons) > 0 {
+		body["rejectionReasons"] = p.reasons
+	}
+}
+
+// parseMinCommentLength parses a non-negative number of characters
+func parseMinCommentLength(value string) (int, error) {
+	length, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if length < 0 {
+		return 0, fmt.Errorf("must not be negative")
+	}
+	return length, nil
+}
+
+// parseRejectionReasons parses a comma separated list of reason codes
+func parseRejectionReasons(value string) ([]string, error) {
+	var reasons []string
+	for _, reason := range strings.Split(value, ",") {
+		reason = strings.TrimSpace(reason)
+		if reason == "" {
+			return nil, fmt.Errorf("empty reason code")
+		}
+		if slices.Contains(reasons, reason) {
+			return nil, fmt.Errorf("reason code '%s' is listed more than once", reason)
+		}
+		reasons = append(reasons, reason)
+	}
+	return reasons, nil
+}
+
+// check returns a validation error naming every rule the decision breaks. Approvals are not
+// checked.
+func (p *rejectionPolicy) check(approvalStatus string, comments string, reasonCode string) error {
+	if approvalStatus != "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED" {
+		return nil
+	}
+
+	var problems []string
+	if problem := p.checkComment(comments); problem != "" {
+		problems = append(problems, problem)
+	}
+	if problem := p.checkReasonCode(reasonCode); problem != "" {
+		problems = append(problems, problem)
+	}
+	if len(problems) > 0 {
+		return validationErrorf("rejection does not meet the policy: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// checkComment describes why the rejection comment is not accepted, it is empty when it is
+func (p *rejectionPolicy) checkComment(comments string) string {
+	length := utf8.RuneCountInString(strings.TrimSpace(comments))
+	switch {
+	case length == 0 && (p.requireComment || p.minCommentLength > 0):
+		return "a comment is required when rejecting"
+	case length < p.minCommentLength:
+		return fmt.Sprintf("the comment must be at least %d characters long, got %d", p.minCommentLength, length)
+	}
+	return ""
+}
+
+// checkReasonCode describes why the rejection reason code is not accepted, it is empty when it is
+func (p *rejectionPolicy) checkReasonCode(reasonCode string) string {
+	if len(p.reasons) == 0 {
+		return ""
+	}
+	switch {
+	case reasonCode == "":
+		return fmt.Sprintf("a reason code is required when rejecting, one of: %s", strings.Join(p.reasons, ", "))
+	case !slices.Contains(p.reasons, reasonCode):
+		return fmt.Sprintf("unknown reason code '%s', expected one of: %s", reasonCode, strings.Join(p.reasons, ", "))
+	}
+	return ""
+}
@@ -0,0 +1,191 @@
+package manual_approval
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_rejectionPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     Config
+		status     string
+		comments   string
+		reasonCode string
+		err        string
+	}{
+		{
+			name:   "no policy",
+			status: "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+		},
+		{
+			name:   "approvals are not checked",
+			config: Config{RequireCommentOnReject: "true", MinCommentLength: "10", RejectionReasons: "security"},
+			status: "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED",
+		},
+		{
+			name:     "required comment",
+			config:   Config{RequireCommentOnReject: "true"},
+			status:   "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			comments: "  ",
+			err:      "rejection does not meet the policy: a comment is required when rejecting",
+		},
+		{
+			name:     "comment too short",
+			config:   Config{MinCommentLength: "10"},
+			status:   "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			comments: "flaky ✗",
+			err:      "rejection does not meet the policy: the comment must be at least 10 characters long, got 7",
+		},
+		{
+			name:     "minimum length requires a comment",
+			config:   Config{MinCommentLength: "10"},
+			status:   "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			comments: "",
+			err:      "rejection does not meet the policy: a comment is required when rejecting",
+		},
+		{
+			name:       "unknown reason code",
+			config:     Config{RejectionReasons: "security, quality ,timing"},
+			status:     "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			reasonCode: "cost",
+			err:        "rejection does not meet the policy: unknown reason code 'cost', expected one of: security, quality, timing",
+		},
+		{
+			name:       "meets the policy",
+			config:     Config{RequireCommentOnReject: "true", MinCommentLength: "10", RejectionReasons: "security,quality,timing"},
+			status:     "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			comments:   "tests are failing on main",
+			reasonCode: "quality",
+		},
+		{
+			name:   "invalid requireCommentOnReject",
+			config: Config{RequireCommentOnReject: "always"},
+			status: "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			err:    "invalid REQUIRE_COMMENT_ON_REJECT value 'always': strconv.ParseBool: parsing \"always\": invalid syntax",
+		},
+		{
+			name:   "negative minCommentLength",
+			config: Config{MinCommentLength: "-1"},
+			status: "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			err:    "invalid MIN_COMMENT_LENGTH value '-1': must not be negative",
+		},
+		{
+			name:   "duplicate rejection reason",
+			config: Config{RejectionReasons: "security,security"},
+			status: "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED",
+			err:    "invalid REJECTION_REASONS value 'security,security': reason code 'security' is listed more than once",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			policy, err := tt.config.rejectionPolicy()
+			if err == nil {
+				err = policy.check(tt.status, tt.comments, tt.reasonCode)
+			}
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tt.err)
+			}
+		})
+	}
+}
+
+func Test_callbackRejectionPolicy(t *testing.T) {
+	tests := []struct {
+		name       string
+		payload    string
+		status     string
+		reasonCode string
+		decisions  int
+		err        string
+	}{
+		{
+			name:       "rejection with reason code",
+			payload:    `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED","comments":"CVE-2026-1234 is not patched","reasonCode":"security","respondedOn":"2026-10-18T12:30:00Z","userName":"jane"}`,
+			status:     `{"message":"Successfully changed workflow manual approval status","status":"REJECTED"}`,
+			reasonCode: "security",
+			decisions:  1,
+		},
+		{
+			name:      "rejection without explanation",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"jane"}`,
+			status:    `{"message":"Invalid approval decision: 'rejection does not meet the policy: a comment is required when rejecting; a reason code is required when rejecting, one of: security, quality, timing'","status":"FAILED"}`,
+			decisions: 0,
+			err:       "rejection does not meet the policy: a comment is required when rejecting; a reason code is required when rejecting, one of: security, quality, timing",
+		},
+		{
+			name:      "approval",
+			payload:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"jane"}`,
+			status:    `{"message":"Successfully changed workflow manual approval status","status":"APPROVED"}`,
+			decisions: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			backend := &fakeBackend{}
+			c := Config{
+				Handler:                "callback",
+				Payload:                tt.payload,
+				RequireCommentOnReject: "true",
+				RejectionReasons:       "security,quality,timing",
+				Backend:                backend,
+				OutputsDir:             dir,
+				StatusFile:             filepath.Join(dir, "status"),
+				Output: &MockStdOut{
+					MockPrintf:  func(format string, a ...any) {},
+					MockPrintln: func(a ...any) {},
+				},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				out, ferr := os.ReadFile(filepath.Join(dir, "reasonCode"))
+				require.NoError(t, ferr)
+				require.Equal(t, tt.reasonCode, string(out))
+			} else {
+				require.EqualError(t, err, tt.err)
+				require.Equal(t, ExitValidation, ExitCode(err))
+			}
+			require.Len(t, backend.decisions, tt.decisions)
+			requireStatusFile(t, tt.status, c.StatusFile)
+		})
+	}
+}
+
+func Test_initRejectionPolicy(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	backend := &fakeBackend{}
+	c := Config{
+		Handler:                "init",
+		RequireCommentOnReject: "true",
+		MinCommentLength:       "10",
+		RejectionReasons:       "security,quality",
+		Backend:                backend,
+		StatusFile:             filepath.Join(dir, "status"),
+		Output:                 &MockStdOut{MockPrintf: func(string, ...any) {}},
+	}
+
+	// Run
+	err := c.init()
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, true, backend.created[0]["requireCommentOnReject"])
+	require.Equal(t, 10, backend.created[0]["minCommentLength"])
+	require.Equal(t, []string{"security", "quality"}, backend.created[0]["rejectionReasons"])
+}
@@ -0,0 +1,193 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// status prints the state of a single approval request
+func (k *Config) status() error {
+	debugf("Inside status handler\n")
+
+	if k.ApprovalID == "" {
+		return configErrorf("approval ID missing")
+	}
+	if err := k.checkFormat(); err != nil {
+		return err
+	}
+
+	approval, err := k.getApproval(k.ApprovalID)
+	if err != nil {
+		return err
+	}
+
+	if k.Format == FormatJSON {
+		return k.printJSON(approval)
+	}
+
+	w := tabwriter.NewWriter(&stdOutWriter{k.Output}, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID:\t%s\n", approval.ID)
+	fmt.Fprintf(w, "Status:\t%s\n", approval.Status)
+	fmt.Fprintf(w, "Approvers:\t%s\n", approverNames(approval.Approvers))
+	fmt.Fprintf(w, "Created:\t%s\n", formatTime(approval.CreatedOn))
+	fmt.Fprintf(w, "Expires:\t%s\n", formatTime(approval.ExpiresOn))
+	fmt.Fprintf(w, "Time remaining:\t%s\n", k.remaining(approval))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	if approval.Instructions != "" {
+		k.Output.Printf("Instructions:\n%s\n", k.renderInstructions(approval.Instructions))
+	}
+	return nil
+}
+
+// list prints the approval requests visible to the user
+func (k *Config) list() error {
+	debugf("Inside list handler\n")
+
+	if err := k.checkFormat(); err != nil {
+		return err
+	}
+	if backend, err := k.backend(); err != nil {
+		return err
+	} else if _, ok := backend.(*platformBackend); !ok {
+		return configErrorf("listing approval requests is only supported by the platform backend")
+	}
+
+	query := url.Values{}
+	if k.Pending {
+		query.Set("status", ApprovalStatusPending)
+	}
+	resp, err := k.get(k.ctx(), "/v1/workflows/approvals", query)
+	if err != nil {
+		k.Output.Printf("ERROR: API call failed with error: '%s'\n", err)
+		k.Output.Printf("ERROR: API response: '%s'\n", resp)
+		return err
+	}
+	debugf("Response: '%s'\n", resp)
+
+	parsedResp := ListManualApprovalResponse{}
+	if err := json.Unmarshal([]byte(resp), &parsedResp); err != nil {
+		return err
+	}
+
+	if k.Format == FormatJSON {
+		if parsedResp.Approvals == nil {
+			parsedResp.Approvals = []ApprovalRequest{}
+		}
+		return k.printJSON(parsedResp.Approvals)
+	}
+
+	if len(parsedResp.Approvals) == 0 {
+		k.Output.Printf("No approval requests found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(&stdOutWriter{k.Output}, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(w, "ID\tSTATUS\tAPPROVERS\tREMAINING\tINSTRUCTIONS\n")
+	for _, approval := range parsedResp.Approvals {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", approval.ID, approval.Status, approverNames(approval.Approvers), k.remaining(&approval), summary(approval.Instructions, 40))
+	}
+	return w.Flush()
+}
+
+// getApproval reads the approval request from the approval backend
+func (k *Config) getApproval(id string) (*ApprovalRequest, error) {
+	backend, err := k.backend()
+	if err != nil {
+		return nil, err
+	}
+	return backend.Get(k.ctx(), id)
+}
+
+func (k *Config) checkFormat() error {
+	switch k.Format {
+	case "":
+		k.Format = FormatTable
+	case FormatTable, FormatJSON:
+	default:
+		return configErrorf("unsupported format: %s", k.Format)
+	}
+	return nil
+}
+
+func (k *Config) printJSON(value interface{}) error {
+	out, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return err
+	}
+	k.Output.Printf("%s\n", out)
+	return nil
+}
+
+func (k *Config) now() time.Time {
+	if k.clock != nil {
+		return k.clock()
+	}
+	return time.Now()
+}
+
+// remaining formats the time left before the approval request expires
+func (k *Config) remaining(approval *ApprovalRequest) string {
+	if approval.Status != ApprovalStatusPending || approval.ExpiresOn.IsZero() {
+		return "-"
+	}
+	left := approval.ExpiresOn.Sub(k.now())
+	if left <= 0 {
+		return "expired"
+	}
+	return left.Round(time.Minute).String()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Format(time.RFC3339)
+}
+
+func approverNames(approvers []Approvers) string {
+	if len(approvers) == 0 {
+		return "-"
+	}
+	names := make([]string, len(approvers))
+	for i, approver := range approvers {
+		names[i] = approver.UserName
+		if names[i] == "" {
+			names[i] = approver.Email
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// summary returns the first line of the text, shortened to at most width runes
+func summary(value string, width int) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(value), "\n")
+	runes := []rune(line)
+	if len(runes) > width {
+		return string(runes[:width-1]) + "…"
+	}
+	if line == "" {
+		return "-"
+	}
+	return line
+}
+
+// stdOutWriter adapts StdOut to io.Writer
+type stdOutWriter struct {
+	out StdOut
+}
+
+func (w *stdOutWriter) Write(p []byte) (int, error) {
+	w.out.Printf("%s", p)
+	return len(p), nil
+}
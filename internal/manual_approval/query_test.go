@@ -0,0 +1,163 @@
+package manual_approval
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const approvalResponse = `{"id":"a-1","status":"PENDING_APPROVAL","approvers":[{"userName":"testUserName","userId":"123","email":"user@mail.com"},{"userId":"456","email":"other@mail.com"}],"instructions":"Check the **dashboard**","createdOn":"2026-10-18T10:00:00Z","expiresOn":"2026-10-18T14:30:00Z"}`
+
+// newMockPlatform starts a server standing in for the platform API. The request bodies it receives
+// are recorded by method and path.
+func newMockPlatform(t *testing.T, routes map[string]string) (*httptest.Server, map[string]string) {
+	received := map[string]string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer test", r.Header.Get("Authorization"))
+		require.Equal(t, "application/json", r.Header.Get("Accept"))
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		received[r.Method+" "+r.URL.RequestURI()] = string(body)
+
+		resp, ok := routes[r.Method+" "+r.URL.RequestURI()]
+		if !ok {
+			http.Error(w, `{"message":"not found"}`, http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(server.Close)
+	return server, received
+}
+
+func Test_status(t *testing.T) {
+	server, _ := newMockPlatform(t, map[string]string{
+		"GET /v1/workflows/approval/a-1": approvalResponse,
+	})
+
+	tests := []struct {
+		name   string
+		config Config
+		output string
+		err    string
+	}{
+		{
+			name:   "table",
+			config: Config{ApprovalID: "a-1", OutputMode: OutputModePlain},
+			output: "ID:              a-1\n" +
+				"Status:          PENDING_APPROVAL\n" +
+				"Approvers:       testUserName,other@mail.com\n" +
+				"Created:         2026-10-18T10:00:00Z\n" +
+				"Expires:         2026-10-18T14:30:00Z\n" +
+				"Time remaining:  2h30m0s\n" +
+				"Instructions:\nCheck the dashboard\n\n",
+		},
+		{
+			name:   "json",
+			config: Config{ApprovalID: "a-1", Format: FormatJSON},
+			output: "{\n  \"id\": \"a-1\",\n  \"status\": \"PENDING_APPROVAL\",\n  \"approvers\": [\n    {\n      \"userName\": \"testUserName\",\n      \"userId\": \"123\",\n      \"email\": \"user@mail.com\"\n    },\n    {\n      \"userName\": \"\",\n      \"userId\": \"456\",\n      \"email\": \"other@mail.com\"\n    }\n  ],\n  \"instructions\": \"Check the **dashboard**\",\n  \"createdOn\": \"2026-10-18T10:00:00Z\",\n  \"expiresOn\": \"2026-10-18T14:30:00Z\"\n}\n",
+		},
+		{
+			name:   "unknown approval",
+			config: Config{ApprovalID: "missing"},
+			output: "ERROR: API call failed with error: 'failed to send event: \nGET " + server.URL + "/v1/workflows/approval/missing\nHTTP/404 404 Not Found\n'\nERROR: API response: '{\"message\":\"not found\"}\n'\n",
+			err:    "failed to send event: \nGET " + server.URL + "/v1/workflows/approval/missing\nHTTP/404 404 Not Found\n",
+		},
+		{
+			name:   "missing ID",
+			config: Config{},
+			err:    "approval ID missing",
+		},
+		{
+			name:   "unsupported format",
+			config: Config{ApprovalID: "a-1", Format: "yaml"},
+			err:    "unsupported format: yaml",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var testOutput strings.Builder
+
+			// Run
+			c := tt.config
+			c.URL = server.URL
+			c.clock = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+			c.Output = &MockStdOut{
+				MockPrintf: func(format string, a ...any) {
+					testOutput.WriteString(fmt.Sprintf(format, a...))
+				},
+			}
+			t.Setenv("API_TOKEN", "test")
+			err := c.status()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+			require.Equal(t, tt.output, testOutput.String())
+		})
+	}
+}
+
+func Test_list(t *testing.T) {
+	server, _ := newMockPlatform(t, map[string]string{
+		"GET /v1/workflows/approvals?status=PENDING_APPROVAL": `{"approvals":[` + approvalResponse + `]}`,
+		"GET /v1/workflows/approvals":                         `{"approvals":[` + approvalResponse + `,{"id":"a-2","status":"APPROVED","instructions":"Deploy to production\nafter the freeze"}]}`,
+	})
+
+	tests := []struct {
+		name   string
+		config Config
+		output string
+	}{
+		{
+			name:   "pending",
+			config: Config{Pending: true},
+			output: "ID   STATUS            APPROVERS                    REMAINING  INSTRUCTIONS\n" +
+				"a-1  PENDING_APPROVAL  testUserName,other@mail.com  2h30m0s    Check the **dashboard**\n",
+		},
+		{
+			name:   "all",
+			config: Config{},
+			output: "ID   STATUS            APPROVERS                    REMAINING  INSTRUCTIONS\n" +
+				"a-1  PENDING_APPROVAL  testUserName,other@mail.com  2h30m0s    Check the **dashboard**\n" +
+				"a-2  APPROVED          -                            -          Deploy to production\n",
+		},
+		{
+			name:   "json",
+			config: Config{Pending: true, Format: FormatJSON},
+			output: "[\n  {\n    \"id\": \"a-1\",\n    \"status\": \"PENDING_APPROVAL\",\n    \"approvers\": [\n      {\n        \"userName\": \"testUserName\",\n        \"userId\": \"123\",\n        \"email\": \"user@mail.com\"\n      },\n      {\n        \"userName\": \"\",\n        \"userId\": \"456\",\n        \"email\": \"other@mail.com\"\n      }\n    ],\n    \"instructions\": \"Check the **dashboard**\",\n    \"createdOn\": \"2026-10-18T10:00:00Z\",\n    \"expiresOn\": \"2026-10-18T14:30:00Z\"\n  }\n]\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var testOutput strings.Builder
+
+			// Run
+			c := tt.config
+			c.URL = server.URL
+			c.clock = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+			c.Output = &MockStdOut{
+				MockPrintf: func(format string, a ...any) {
+					testOutput.WriteString(fmt.Sprintf(format, a...))
+				},
+			}
+			t.Setenv("API_TOKEN", "test")
+			err := c.list()
+
+			// Verify
+			require.NoError(t, err)
+			require.Equal(t, tt.output, testOutput.String())
+		})
+	}
+}
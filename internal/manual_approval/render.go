@@ -0,0 +1,347 @@
+package manual_approval
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+)
+
+const (
+	OutputModeHTML  = "html"
+	OutputModeANSI  = "ansi"
+	OutputModePlain = "plain"
+)
+
+const (
+	ansiReset     = "\x1b[0m"
+	ansiBold      = "\x1b[1m"
+	ansiDim       = "\x1b[2m"
+	ansiItalic    = "\x1b[3m"
+	ansiUnderline = "\x1b[4m"
+	ansiCyan      = "\x1b[36m"
+)
+
+const defaultTerminalWidth = 80
+
+// resolveOutputMode picks the output mode from the configuration, the
+// OUTPUT_MODE environment variable or, when neither is set, from whether
+// stdout is attached to a terminal.
+func resolveOutputMode(mode string) (string, error) {
+	if mode == "" {
+		mode = os.Getenv("OUTPUT_MODE")
+	}
+
+	switch mode {
+	case OutputModeHTML, OutputModeANSI, OutputModePlain:
+		return mode, nil
+	case "", "auto":
+		if !isTerminal(os.Stdout) {
+			return OutputModeHTML, nil
+		}
+		if os.Getenv("NO_COLOR") != "" {
+			return OutputModePlain, nil
+		}
+		return OutputModeANSI, nil
+	default:
+		return "", configErrorf("unsupported output mode: %s", mode)
+	}
+}
+
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+func (k *Config) outputMode() string {
+	if k.OutputMode == "" {
+		return OutputModeHTML
+	}
+	return k.OutputMode
+}
+
+// renderInstructions renders markdown instructions for the configured output mode
+func (k *Config) renderInstructions(value string) string {
+	switch k.outputMode() {
+	case OutputModeANSI:
+		return terminalMarkdown(value, true, terminalWidth())
+	case OutputModePlain:
+		return terminalMarkdown(value, false, terminalWidth())
+	default:
+		return markdown(value)
+	}
+}
+
+func terminalWidth() int {
+	if columns, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && columns > 20 {
+		return columns
+	}
+	return defaultTerminalWidth
+}
+
+// terminalMarkdown renders markdown as wrapped terminal text, optionally
+// decorated with ANSI escape sequences
+func terminalMarkdown(value string, ansi bool, width int) string {
+	source := []byte(value)
+	doc := goldmark.New().Parser().Parse(text.NewReader(source))
+
+	r := &terminalRenderer{source: source, ansi: ansi}
+	lines := r.blocks(doc, width)
+	if len(lines) == 0 {
+		return ""
+	}
+	return strings.Join(lines, "\n") + "\n"
+}
+
+type terminalRenderer struct {
+	source []byte
+	ansi   bool
+}
+
+// blocks renders the block children of the node into lines no wider than width
+func (r *terminalRenderer) blocks(parent ast.Node, width int) []string {
+	var lines []string
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		block := r.block(n, width)
+		if len(block) == 0 {
+			continue
+		}
+		// Tight list items keep their text in text blocks which are not followed by a blank line
+		if len(lines) > 0 && n.PreviousSibling().Kind() != ast.KindTextBlock {
+			lines = append(lines, "")
+		}
+		lines = append(lines, block...)
+	}
+	return lines
+}
+
+func (r *terminalRenderer) block(n ast.Node, width int) []string {
+	switch n := n.(type) {
+	case *ast.Heading:
+		content := r.inlines(n)
+		switch {
+		case r.ansi && n.Level == 1:
+			return wrap(r.style(content, ansiBold+ansiUnderline), width)
+		case r.ansi:
+			return wrap(r.style(content, ansiBold), width)
+		case n.Level <= 2:
+			lines := wrap(content, width)
+			underline := "="
+			if n.Level == 2 {
+				underline = "-"
+			}
+			return append(lines, strings.Repeat(underline, visibleLen(lines[len(lines)-1])))
+		default:
+			return wrap(content, width)
+		}
+	case *ast.Paragraph, *ast.TextBlock:
+		return wrap(r.inlines(n), width)
+	case *ast.Blockquote:
+		bar := "> "
+		if r.ansi {
+			bar = ansiDim + "│" + ansiReset + " "
+		}
+		return prefixLines(r.blocks(n, width-2), bar, bar)
+	case *ast.List:
+		return r.list(n, width)
+	case *ast.FencedCodeBlock, *ast.CodeBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, r.style(strings.TrimRight(string(line.Value(r.source)), "\n"), ansiCyan))
+		}
+		return prefixLines(lines, "    ", "    ")
+	case *ast.HTMLBlock:
+		var lines []string
+		for i := 0; i < n.Lines().Len(); i++ {
+			line := n.Lines().At(i)
+			lines = append(lines, strings.TrimRight(string(line.Value(r.source)), "\n"))
+		}
+		return lines
+	case *ast.ThematicBreak:
+		return []string{strings.Repeat("─", width)}
+	default:
+		return r.blocks(n, width)
+	}
+}
+
+func (r *terminalRenderer) list(n *ast.List, width int) []string {
+	var lines []string
+	glyph := bulletGlyph(n)
+	number := n.Start
+	for item := n.FirstChild(); item != nil; item = item.NextSibling() {
+		marker := glyph + " "
+		if n.IsOrdered() {
+			marker = fmt.Sprintf("%d%c ", number, n.Marker)
+			number++
+		}
+		indent := strings.Repeat(" ", utf8.RuneCountInString(marker))
+		if !n.IsTight && len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, prefixLines(r.blocks(item, width-len(indent)), marker, indent)...)
+	}
+	return lines
+}
+
+// bulletGlyph picks a bullet for the list based on how deeply it is nested
+func bulletGlyph(n ast.Node) string {
+	depth := 0
+	for p := n.Parent(); p != nil; p = p.Parent() {
+		if p.Kind() == ast.KindList {
+			depth++
+		}
+	}
+	return []string{"•", "◦", "▪"}[depth%3]
+}
+
+// inlines renders the inline children of the node as a single string in which
+// "\n" marks hard line breaks
+func (r *terminalRenderer) inlines(parent ast.Node) string {
+	var sb strings.Builder
+	for n := parent.FirstChild(); n != nil; n = n.NextSibling() {
+		switch n := n.(type) {
+		case *ast.Text:
+			sb.Write(n.Segment.Value(r.source))
+			if n.HardLineBreak() {
+				sb.WriteString("\n")
+			} else if n.SoftLineBreak() {
+				sb.WriteString(" ")
+			}
+		case *ast.String:
+			sb.Write(n.Value)
+		case *ast.CodeSpan:
+			code := r.inlines(n)
+			if r.ansi {
+				sb.WriteString(r.style(code, ansiCyan))
+			} else {
+				sb.WriteString("`" + code + "`")
+			}
+		case *ast.Emphasis:
+			if n.Level >= 2 {
+				sb.WriteString(r.style(r.inlines(n), ansiBold))
+			} else {
+				sb.WriteString(r.style(r.inlines(n), ansiItalic))
+			}
+		case *ast.Link:
+			label := r.inlines(n)
+			sb.WriteString(r.style(label, ansiUnderline))
+			if label != string(n.Destination) {
+				sb.WriteString(" (" + string(n.Destination) + ")")
+			}
+		case *ast.AutoLink:
+			sb.WriteString(r.style(string(n.URL(r.source)), ansiUnderline))
+		case *ast.Image:
+			sb.WriteString(r.inlines(n))
+		case *ast.RawHTML:
+			for i := 0; i < n.Segments.Len(); i++ {
+				segment := n.Segments.At(i)
+				sb.Write(segment.Value(r.source))
+			}
+		default:
+			sb.WriteString(r.inlines(n))
+		}
+	}
+	return sb.String()
+}
+
+// style decorates every word separately so that wrapping never splits an escape sequence
+// from the text it applies to
+func (r *terminalRenderer) style(value string, codes string) string {
+	if !r.ansi || value == "" {
+		return value
+	}
+	words := strings.Split(value, " ")
+	for i, word := range words {
+		if word != "" {
+			words[i] = codes + word + ansiReset
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// wrap splits the text into lines no wider than width, keeping hard line breaks
+func wrap(value string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(value, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			switch {
+			case line == "":
+				line = word
+			case visibleLen(line)+1+visibleLen(word) > width:
+				lines = append(lines, line)
+				line = word
+			default:
+				line += " " + word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func prefixLines(lines []string, first string, rest string) []string {
+	out := make([]string, len(lines))
+	for i, line := range lines {
+		prefix := rest
+		if i == 0 {
+			prefix = first
+		}
+		if line == "" {
+			out[i] = strings.TrimRight(prefix, " ")
+		} else {
+			out[i] = prefix + line
+		}
+	}
+	return out
+}
+
+// visibleLen counts the runes of the value that are not part of ANSI escape sequences
+func visibleLen(value string) int {
+	length := 0
+	escape := false
+	for _, c := range value {
+		switch {
+		case escape:
+			escape = c != 'm'
+		case c == '\x1b':
+			escape = true
+		default:
+			length++
+		}
+	}
+	return length
+}
+
+// formatInputsTable lays out input parameter names and values as an aligned
+// key/value table, indenting continuation lines of multi-line values
+func (k *Config) formatInputsTable(rows [][2]string) []string {
+	nameWidth := 0
+	for _, row := range rows {
+		nameWidth = max(nameWidth, utf8.RuneCountInString(row[0]))
+	}
+
+	var lines []string
+	for _, row := range rows {
+		name := row[0] + strings.Repeat(" ", nameWidth-utf8.RuneCountInString(row[0]))
+		if k.outputMode() == OutputModeANSI {
+			name = ansiBold + name + ansiReset
+		}
+		valueLines := strings.Split(row[1], "\n")
+		indent := strings.Repeat(" ", nameWidth+4)
+		lines = append(lines, " "+name+" : "+valueLines[0]+"\n")
+		for _, line := range valueLines[1:] {
+			lines = append(lines, indent+line+"\n")
+		}
+	}
+	return lines
+}
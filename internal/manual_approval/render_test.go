@@ -0,0 +1,192 @@
+package manual_approval
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_resolveOutputMode(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		env    map[string]string
+		output string
+		err    string
+	}{
+		{
+			name:   "configured mode",
+			mode:   "plain",
+			output: OutputModePlain,
+		},
+		{
+			name:   "mode from environment variable",
+			env:    map[string]string{"OUTPUT_MODE": "ansi"},
+			output: OutputModeANSI,
+		},
+		{
+			name:   "configured mode wins over environment variable",
+			mode:   "html",
+			env:    map[string]string{"OUTPUT_MODE": "ansi"},
+			output: OutputModeHTML,
+		},
+		{
+			name:   "auto mode without terminal",
+			mode:   "auto",
+			output: OutputModeHTML,
+		},
+		{
+			name: "unsupported mode",
+			mode: "markdown",
+			err:  "unsupported output mode: markdown",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer func(k string) {
+					os.Unsetenv(k)
+				}(k)
+			}
+
+			// Run
+			mode, err := resolveOutputMode(tt.mode)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tt.output, mode)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func Test_terminalMarkdown(t *testing.T) {
+	tests := []struct {
+		name   string
+		input  string
+		ansi   bool
+		width  int
+		output string
+	}{
+		{
+			name:   "plain",
+			input:  instructionsInput,
+			width:  40,
+			output: "instruction `instruction2`\n\ninstruction3\n============\n\ninstruction4\n------------\n\ninstruction5\n\n> Blockquotes can contain multiple\n> paragraphs\n>\n> Add a > on the blank lines between the\n> paragraps.\n\n• Rirst item\n• Second Item\n• Third item\n  ◦ Indented item\n  ◦ Indented item\n• Fourth item\n",
+		},
+		{
+			name:   "plain ordered list with link",
+			input:  "1. Check the [dashboard](https://example.com) before approving the deployment\n2. Approve",
+			width:  40,
+			output: "1. Check the dashboard\n   (https://example.com) before\n   approving the deployment\n2. Approve\n",
+		},
+		{
+			name:   "ansi",
+			input:  "# Deploy\n\nRun **all** the `checks`\n\n- one",
+			ansi:   true,
+			width:  80,
+			output: "\x1b[1m\x1b[4mDeploy\x1b[0m\n\nRun \x1b[1mall\x1b[0m the \x1b[36mchecks\x1b[0m\n\n• one\n",
+		},
+		{
+			name:   "ansi wrapping ignores escape sequences",
+			input:  "**aaaa bbbb** cccc",
+			ansi:   true,
+			width:  10,
+			output: "\x1b[1maaaa\x1b[0m \x1b[1mbbbb\x1b[0m\ncccc\n",
+		},
+		{
+			name:   "code block",
+			input:  "```\nkubectl rollout undo\n```",
+			width:  80,
+			output: "    kubectl rollout undo\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Run
+			result := terminalMarkdown(tt.input, tt.ansi, tt.width)
+
+			// Verify
+			require.Equal(t, tt.output, result)
+		})
+	}
+}
+
+func Test_formatInputsValsAndWriteToLog(t *testing.T) {
+	tests := []struct {
+		name   string
+		mode   string
+		inputs []interface{}
+		output []string
+	}{
+		{
+			name: "html",
+			mode: OutputModeHTML,
+			inputs: []interface{}{
+				map[string]interface{}{"name": "reason", "value": "line1\nline2", "is_default": false},
+				map[string]interface{}{"name": "replicas", "value": "3", "is_default": true},
+			},
+			output: []string{
+				"\nInput Parameters:\n",
+				"------------------\n",
+				" reason: line1<br/>line2 \n",
+				" replicas: 3 (default) \n",
+			},
+		},
+		{
+			name: "plain",
+			mode: OutputModePlain,
+			inputs: []interface{}{
+				map[string]interface{}{"name": "reason", "value": "line1\nline2", "is_default": false},
+				map[string]interface{}{"name": "replicas", "value": "3", "is_default": true},
+			},
+			output: []string{
+				"\nInput Parameters:\n",
+				"------------------\n",
+				" reason   : line1\n",
+				"            line2\n",
+				" replicas : 3 (default)\n",
+			},
+		},
+		{
+			name: "ansi",
+			mode: OutputModeANSI,
+			inputs: []interface{}{
+				map[string]interface{}{"name": "ok", "value": "true", "is_default": false},
+			},
+			output: []string{
+				"\nInput Parameters:\n",
+				"------------------\n",
+				" \x1b[1mok\x1b[0m : true\n",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var testOutput []string
+
+			// Run
+			c := Config{
+				OutputMode: tt.mode,
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						testOutput = append(testOutput, fmt.Sprintf(format, a...))
+					},
+				},
+			}
+			c.formatInputsValsAndWriteToLog(tt.inputs)
+
+			// Verify
+			require.True(t, slices.Equal(tt.output, testOutput), "%q", testOutput)
+		})
+	}
+}
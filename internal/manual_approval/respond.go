@@ -0,0 +1,155 @@
+package manual_approval
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+)
+
+const approvalStatusPath = "/v1/workflows/approval/status"
+
+// respond posts the approve or reject decision of the user owning the API token
+func (k *Config) respond(approve bool) error {
+	debugf("Inside respond handler\n")
+
+	if k.ApprovalID == "" {
+		return configErrorf("approval ID missing")
+	}
+
+	values, err := parseInputValues(k.InputValues)
+	if err != nil {
+		return err
+	}
+
+	approval, err := k.getApproval(k.ApprovalID)
+	if err != nil {
+		return err
+	}
+	if approval.Status != ApprovalStatusPending {
+		return fmt.Errorf("approval request %s is not waiting for a response: %s", approval.ID, approval.Status)
+	}
+
+	schema, err := usableApprovalInputs(approval.ApprovalInputs)
+	if err != nil {
+		return err
+	}
+	inputs, err := inputsFromValues(schema, values, approve)
+	if err != nil {
+		return err
+	}
+
+	status := "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED"
+	if approve {
+		status = "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED"
+	}
+
+	policy, err := k.rejectionPolicy()
+	if err != nil {
+		return err
+	}
+	if err := policy.check(status, k.Comments, k.ReasonCode); err != nil {
+		return err
+	}
+
+	// Build the decision in the same shape as the callback handler payload
+	decision := map[string]interface{}{
+		"id":          approval.ID,
+		"status":      status,
+		"comments":    k.Comments,
+		"respondedOn": k.now().UTC().Format(time.RFC3339),
+		"inputs":      inputs,
+	}
+	if k.ReasonCode != "" {
+		decision["reasonCode"] = k.ReasonCode
+	}
+
+	modifiedInputsParamForPost, _, err := formatInputsForPost(decision)
+	if err != nil {
+		return err
+	}
+
+	backend, err := k.backend()
+	if err != nil {
+		return err
+	}
+	if err := backend.UpdateStatus(k.ctx(), decision); err != nil {
+		return err
+	}
+
+	if approve {
+		k.Output.Printf("Approved %s\n", approval.ID)
+	} else {
+		k.Output.Printf("Rejected %s\n", approval.ID)
+	}
+	k.formatInputsValsAndWriteToLog(modifiedInputsParamForPost)
+	return nil
+}
+
+// parseInputValues parses name=value pairs
+func parseInputValues(pairs []string) (map[string]string, error) {
+	values := map[string]string{}
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, validationErrorf("invalid input '%s', expected name=value", pair)
+		}
+		if _, exists := values[name]; exists {
+			return nil, validationErrorf("input '%s' is given more than once", name)
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+// inputsFromValues checks the values against the input schema and builds the inputs of an
+// approval decision, falling back to the default values of inputs that are not given. Inputs whose
+// when conditions are not met are left out. Required inputs only have to be given when approving,
+// like the callback handler checks them.
+func inputsFromValues(schema []ApprovalInput, values map[string]string, approved bool) ([]interface{}, error) {
+	known := map[string]bool{}
+	parsed := map[string]interface{}{}
+	defaults := map[string]bool{}
+	for _, input := range schema {
+		known[input.Name] = true
+
+		value, ok := values[input.Name]
+		if !ok {
+			if input.Default != nil {
+				parsed[input.Name] = input.Default
+				defaults[input.Name] = true
+			}
+			continue
+		}
+
+		typed, err := input.parseValue(value)
+		if err != nil {
+			return nil, validationErrorf("invalid value for input '%s': %w", input.Name, err)
+		}
+		parsed[input.Name] = typed
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if !known[name] {
+			return nil, validationErrorf("unknown input '%s'", name)
+		}
+	}
+
+	applicable := applicableInputs(schema, parsed)
+	inputs := []interface{}{}
+	for _, input := range schema {
+		if !applicable[input.Name] {
+			continue
+		}
+		value, ok := parsed[input.Name]
+		if !ok {
+			if input.Required && approved {
+				return nil, validationErrorf("input '%s' is required", input.Name)
+			}
+			continue
+		}
+		inputs = append(inputs, map[string]interface{}{"name": input.Name, "value": value, "is_default": defaults[input.Name]})
+	}
+	return inputs, nil
+}
@@ -0,0 +1,128 @@
+package manual_approval
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_respond(t *testing.T) {
+	const inputsApproval = `{"id":"a-1","status":"PENDING_APPROVAL","approvalInputs":"reason:\n  type: string\n  required: true\nreplicas:\n  type: number\n  default: 3\nenv:\n  type: choice\n  options: [staging, production]"}`
+
+	tests := []struct {
+		name     string
+		approve  bool
+		config   Config
+		approval string
+		request  string
+		output   string
+		err      string
+	}{
+		{
+			name:     "approve with inputs",
+			approve:  true,
+			config:   Config{Comments: "lgtm", InputValues: []string{"reason=hotfix", "env=production"}},
+			approval: inputsApproval,
+			request:  `{"comments":"lgtm","id":"a-1","inputs":[{"is_default":false,"name":"reason","value":"hotfix"},{"is_default":true,"name":"replicas","value":"3"},{"is_default":false,"name":"env","value":"production"}],"respondedOn":"2026-10-18T12:00:00Z","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED"}`,
+			output:   "Approved a-1\n\nInput Parameters:\n------------------\n reason: hotfix \n replicas: 3 (default) \n env: production \n",
+		},
+		{
+			name:     "reject without inputs",
+			config:   Config{Comments: "not now"},
+			approval: `{"id":"a-1","status":"PENDING_APPROVAL"}`,
+			request:  `{"comments":"not now","id":"a-1","inputs":[],"respondedOn":"2026-10-18T12:00:00Z","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED"}`,
+			output:   "Rejected a-1\n",
+		},
+		{
+			name:     "reject with reason code",
+			config:   Config{Comments: "CVE-2026-1234", ReasonCode: "security", RejectionReasons: "security,quality", RequireCommentOnReject: "true"},
+			approval: `{"id":"a-1","status":"PENDING_APPROVAL"}`,
+			request:  `{"comments":"CVE-2026-1234","id":"a-1","inputs":[],"reasonCode":"security","respondedOn":"2026-10-18T12:00:00Z","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED"}`,
+			output:   "Rejected a-1\n",
+		},
+		{
+			name:     "reject against the policy",
+			config:   Config{RejectionReasons: "security,quality", RequireCommentOnReject: "true"},
+			approval: `{"id":"a-1","status":"PENDING_APPROVAL"}`,
+			err:      "rejection does not meet the policy: a comment is required when rejecting; a reason code is required when rejecting, one of: security, quality",
+		},
+		{
+			name:     "missing required input",
+			approve:  true,
+			config:   Config{InputValues: []string{"env=staging"}},
+			approval: inputsApproval,
+			err:      "input 'reason' is required",
+		},
+		{
+			name:     "reject without required input",
+			config:   Config{Comments: "not now", InputValues: []string{"env=staging"}},
+			approval: inputsApproval,
+			request:  `{"comments":"not now","id":"a-1","inputs":[{"is_default":true,"name":"replicas","value":"3"},{"is_default":false,"name":"env","value":"staging"}],"respondedOn":"2026-10-18T12:00:00Z","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED"}`,
+			output:   "Rejected a-1\n\nInput Parameters:\n------------------\n replicas: 3 (default) \n env: staging \n",
+		},
+		{
+			name:     "invalid input value",
+			approve:  true,
+			config:   Config{InputValues: []string{"reason=x", "replicas=many"}},
+			approval: inputsApproval,
+			err:      "invalid value for input 'replicas': 'many' is not a number",
+		},
+		{
+			name:     "unknown input",
+			approve:  true,
+			config:   Config{InputValues: []string{"reason=x", "region=eu"}},
+			approval: inputsApproval,
+			err:      "unknown input 'region'",
+		},
+		{
+			name:     "malformed input",
+			approve:  true,
+			config:   Config{InputValues: []string{"reason"}},
+			approval: inputsApproval,
+			err:      "invalid input 'reason', expected name=value",
+		},
+		{
+			name:     "already approved",
+			approve:  true,
+			approval: `{"id":"a-1","status":"APPROVED"}`,
+			err:      "approval request a-1 is not waiting for a response: APPROVED",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, received := newMockPlatform(t, map[string]string{
+				"GET /v1/workflows/approval/a-1":     tt.approval,
+				"POST /v1/workflows/approval/status": `{}`,
+			})
+
+			var testOutput strings.Builder
+
+			// Run
+			c := tt.config
+			c.ApprovalID = "a-1"
+			c.URL = server.URL
+			c.clock = func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) }
+			c.Output = &MockStdOut{
+				MockPrintf: func(format string, a ...any) {
+					testOutput.WriteString(fmt.Sprintf(format, a...))
+				},
+			}
+			t.Setenv("API_TOKEN", "test")
+			err := c.respond(tt.approve)
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.JSONEq(t, tt.request, received["POST /v1/workflows/approval/status"])
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+				require.NotContains(t, received, "POST /v1/workflows/approval/status")
+			}
+			require.Equal(t, tt.output, testOutput.String())
+		})
+	}
+}
@@ -0,0 +1,339 @@
+package manual_approval
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// approvalStore keeps the decision records of approvals, so a later job requesting approval of the
+// same approval key can reuse them
+type approvalStore interface {
+	// find returns the records saved for the approval key
+	find(ctx context.Context, key string) ([]DecisionRecord, error)
+	// save adds the record
+	save(ctx context.Context, record DecisionRecord) error
+}
+
+// reusePolicy is the approval key of the job, the store its approvals are saved to and how long they
+// can be reused. Approvals are saved but not reused when the window is zero.
+type reusePolicy struct {
+	key    string
+	window time.Duration
+	store  approvalStore
+}
+
+// reusePolicy reads the policy from the configuration, falling back to the APPROVAL_KEY,
+// REUSE_WINDOW, APPROVAL_STORE and APPROVAL_STORE_TOKEN environment variables. It is nil when no
+// approval key is set.
+func (k *Config) reusePolicy() (*reusePolicy, error) {
+	key := strings.TrimSpace(valueOrEnv(k.ApprovalKey, "APPROVAL_KEY"))
+	window := valueOrEnv(k.ReuseWindow, "REUSE_WINDOW")
+	location := valueOrEnv(k.ApprovalStore, "APPROVAL_STORE")
+	if key == "" {
+		if window != "" {
+			return nil, configErrorf("REUSE_WINDOW requires APPROVAL_KEY")
+		}
+		return nil, nil
+	}
+	if location == "" {
+		return nil, configErrorf("APPROVAL_KEY requires APPROVAL_STORE")
+	}
+
+	policy := &reusePolicy{key: key}
+	if window != "" {
+		duration, err := parseReuseWindow(window)
+		if err != nil {
+			return nil, configErrorf("invalid REUSE_WINDOW value '%s': %w", window, err)
+		}
+		policy.window = duration
+	}
+	store, err := k.newApprovalStore(location)
+	if err != nil {
+		return nil, configErrorf("invalid APPROVAL_STORE value '%s': %w", location, err)
+	}
+	policy.store = store
+	return policy, nil
+}
+
+// parseReuseWindow parses a positive duration such as 12h or 30m
+func parseReuseWindow(value string) (time.Duration, error) {
+	window, err := time.ParseDuration(strings.TrimSpace(value))
+	if err != nil {
+		return 0, err
+	}
+	if window <= 0 {
+		return 0, fmt.Errorf("must be positive")
+	}
+	return window, nil
+}
+
+// newApprovalStore returns the HTTP store for http and https URLs and the file store otherwise
+func (k *Config) newApprovalStore(location string) (approvalStore, error) {
+	isHTTP, err := isHTTPStore(location)
+	if err != nil {
+		return nil, err
+	}
+	if !isHTTP {
+		return &fileStore{file: location}, nil
+	}
+	return &httpStore{config: k, url: location, token: valueOrEnv(k.ApprovalStoreToken, "APPROVAL_STORE_TOKEN")}, nil
+}
+
+// isHTTPStore reports whether the approval store is an http or https URL and checks the URL
+func isHTTPStore(location string) (bool, error) {
+	if !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		return false, nil
+	}
+	if _, err := url.ParseRequestURI(location); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+// reusableApproval returns the latest approval of the approval key that was given within the reuse
+// window by an approver who is still allowed to approve
+func (k *Config) reusableApproval(policy *reusePolicy, records []DecisionRecord, disallowed []disallowedApprover) *DecisionRecord {
+	now := k.now()
+	var latest *DecisionRecord
+	var latestOn time.Time
+	for i, record := range records {
+		if record.ApprovalKey != policy.key || record.Decision != "APPROVED" {
+			continue
+		}
+		respondedOn, err := time.Parse(time.RFC3339, record.RespondedOn)
+		if err != nil {
+			k.Output.Printf("Approval request %s cannot be reused, invalid respondedOn '%s': %s\n", record.ApprovalID, record.RespondedOn, err)
+			continue
+		}
+		if respondedOn.After(now) || now.Sub(respondedOn) > policy.window {
+			continue
+		}
+		if d := findDisallowed(disallowed, record.Approver, record.ApproverID); d != nil {
+			k.Output.Printf("Approval request %s cannot be reused, approver %s is not allowed to approve (%s)\n", record.ApprovalID, record.Approver, d.reason)
+			continue
+		}
+		if latest == nil || respondedOn.After(latestOn) {
+			latest, latestOn = &records[i], respondedOn
+		}
+	}
+	return latest
+}
+
+// reuseApproval approves the job with an earlier approval of the same approval key when there is
+// one within the reuse window. Without one, or when the store cannot be read, a new approval has to
+// be requested.
+func (k *Config) reuseApproval() (bool, error) {
+	policy, err := k.reusePolicy()
+	var disallowed []disallowedApprover
+	if err == nil && policy != nil && policy.window > 0 {
+		disallowed, err = k.disallowedApprovers()
+	}
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Failed to initialize workflow manual approval request: '%s'", err), err)
+		if ferr != nil {
+			return false, ferr
+		}
+		return false, err
+	}
+	if policy == nil || policy.window == 0 {
+		return false, nil
+	}
+
+	records, err := policy.store.find(k.ctx(), policy.key)
+	if err != nil {
+		k.Output.Printf("Failed to read the approval store, requesting a new approval: %s\n", err)
+		return false, nil
+	}
+	record := k.reusableApproval(policy, records, disallowed)
+	if record == nil {
+		debugf("No reusable approval for approval key '%s'\n", policy.key)
+		return false, nil
+	}
+
+	k.statusDetails.approvalID = record.ApprovalID
+	k.statusDetails.decision = record.Decision
+	k.statusDetails.approver = record.Approver
+	k.statusDetails.approverID = record.ApproverID
+	k.statusDetails.respondedOn = record.RespondedOn
+	k.Output.Printf("Reusing approval request %s for %s, approved by %s on %s\n", record.ApprovalID, policy.key, record.Approver, record.RespondedOn)
+
+	out, err := json.Marshal(record)
+	if err != nil {
+		return false, err
+	}
+	if err := k.writeAsOutput("decisionRecord", out); err != nil {
+		return false, err
+	}
+	if err := k.writeAsOutput("reusedApprovalId", []byte(record.ApprovalID)); err != nil {
+		return false, err
+	}
+	return true, k.writeStatus("APPROVED", fmt.Sprintf("Reused approval request %s", record.ApprovalID))
+}
+
+// saveApproval adds an approval to the approval store, with the time it is saved at next to the time
+// the approver responded. The decision is already recorded, so failing to save it is logged and only
+// keeps it from being reused.
+func (k *Config) saveApproval(record DecisionRecord) {
+	if record.Decision != "APPROVED" {
+		return
+	}
+	record.SavedOn = k.now().UTC().Format(time.RFC3339)
+	policy, err := k.reusePolicy()
+	if err == nil && policy == nil {
+		return
+	}
+	if err == nil && k.DryRun {
+		k.Output.Printf("Dry run: approval for %s is not saved to the approval store\n", policy.key)
+		return
+	}
+	if err == nil {
+		err = policy.store.save(k.ctx(), record)
+	}
+	if err != nil {
+		k.Output.Printf("Failed to save the approval to the approval store: %s\n", err)
+	}
+}
+
+// fileStore keeps the decision records in a file, one per line
+type fileStore struct {
+	file string
+}
+
+func (s *fileStore) find(_ context.Context, key string) ([]DecisionRecord, error) {
+	content, err := os.ReadFile(s.file)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	records, err := parseDecisionRecords(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("invalid approval store %s: %w", s.file, err)
+	}
+	return records, nil
+}
+
+func (s *fileStore) save(_ context.Context, record DecisionRecord) error {
+	line, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(s.file, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// httpStore reads the decision records of an approval key with GET <url>?approvalKey=<key> and adds
+// records with POST <url>. Responses list the records as a JSON list or one per line, 404 stands for
+// no records.
+type httpStore struct {
+	config *Config
+	url    string
+	token  string
+}
+
+func (s *httpStore) find(ctx context.Context, key string) ([]DecisionRecord, error) {
+	separator := "?"
+	if strings.Contains(s.url, "?") {
+		separator = "&"
+	}
+	resp, err := s.do(ctx, http.MethodGet, s.url+separator+url.Values{"approvalKey": {key}}.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.code == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := resp.err(); err != nil {
+		return nil, err
+	}
+	records, err := parseDecisionRecords(resp.body)
+	if err != nil {
+		return nil, fmt.Errorf("invalid approval store response: %w", err)
+	}
+	return records, nil
+}
+
+func (s *httpStore) save(ctx context.Context, record DecisionRecord) error {
+	body, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, s.url, body)
+	if err != nil {
+		return err
+	}
+	return resp.err()
+}
+
+// storeResponse is the status and the body of an approval store response
+type storeResponse struct {
+	method string
+	url    string
+	status string
+	code   int
+	body   string
+}
+
+// err describes a response with a status code other than 2xx
+func (r *storeResponse) err() error {
+	if r.code/100 != 2 {
+		return fmt.Errorf("%s %s returned %s", r.method, r.url, r.status)
+	}
+	return nil
+}
+
+func (s *httpStore) do(ctx context.Context, method string, requestURL string, body []byte) (*storeResponse, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+	if s.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", s.token))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+
+	if s.config.DryRun {
+		// a dry run finds no records and saveApproval does not save any
+		s.config.logDryRunRequest(req, body)
+		return &storeResponse{method: method, url: s.url, status: "404 Not Found", code: http.StatusNotFound}, nil
+	}
+
+	client, err := s.config.httpClient()
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	responseBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return &storeResponse{method: method, url: s.url, status: resp.Status, code: resp.StatusCode, body: string(responseBody)}, nil
+}
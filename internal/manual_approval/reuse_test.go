@@ -0,0 +1,249 @@
+package manual_approval
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const imageDigest = "sha256:4f53"
+
+// approvalStoreRecords are the approvals in the store of Test_initReuseApproval, the latest one was
+// given 2 hours before the test time
+var approvalStoreRecords = strings.Join([]string{
+	`{"schemaVersion":1,"approvalId":"a-0","decision":"APPROVED","approver":"joe","respondedOn":"2026-10-18T06:00:00Z","approvalKey":"sha256:4f53"}`,
+	`{"schemaVersion":1,"approvalId":"a-1","decision":"APPROVED","approver":"jane","approverId":"42","respondedOn":"2026-10-18T10:00:00Z","approvalKey":"sha256:4f53"}`,
+	`{"schemaVersion":1,"approvalId":"a-2","decision":"REJECTED","approver":"bob","respondedOn":"2026-10-18T11:00:00Z","approvalKey":"sha256:4f53"}`,
+	`{"schemaVersion":1,"approvalId":"a-3","decision":"APPROVED","approver":"bob","respondedOn":"2026-10-18T11:00:00Z","approvalKey":"sha256:9e1a"}`,
+}, "\n") + "\n"
+
+func Test_initReuseApproval(t *testing.T) {
+	tests := []struct {
+		name         string
+		store        string
+		reuseWindow  string
+		distinctFrom string
+		reused       string
+		status       string
+		output       string
+		err          string
+	}{
+		{
+			name:        "latest approval within the window",
+			store:       approvalStoreRecords,
+			reuseWindow: "3h",
+			reused:      "a-1",
+			status:      `{"message":"Reused approval request a-1","status":"APPROVED"}`,
+			output:      "Reusing approval request a-1 for sha256:4f53, approved by jane on 2026-10-18T10:00:00Z\n",
+		},
+		{
+			name:        "approvals outside the window",
+			store:       approvalStoreRecords,
+			reuseWindow: "90m",
+			status:      `{"message":"Waiting for approval from approvers","status":"PENDING_APPROVAL"}`,
+			output:      "Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:         "approver of an earlier stage",
+			store:        approvalStoreRecords,
+			reuseWindow:  "12h",
+			distinctFrom: `{"schemaVersion":1,"approvalId":"s-1","decision":"APPROVED","approver":"jane","approverId":"42"}`,
+			reused:       "a-0",
+			status:       `{"message":"Reused approval request a-0","status":"APPROVED"}`,
+			output: "Approval request a-1 cannot be reused, approver jane is not allowed to approve (approved the earlier approval request s-1)\n" +
+				"Reusing approval request a-0 for sha256:4f53, approved by joe on 2026-10-18T06:00:00Z\n",
+		},
+		{
+			name:   "approvals saved but not reused",
+			store:  approvalStoreRecords,
+			status: `{"message":"Waiting for approval from approvers","status":"PENDING_APPROVAL"}`,
+			output: "Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:        "empty store",
+			reuseWindow: "3h",
+			status:      `{"message":"Waiting for approval from approvers","status":"PENDING_APPROVAL"}`,
+			output:      "Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:        "unreadable store",
+			store:       "approved\n",
+			reuseWindow: "3h",
+			status:      `{"message":"Waiting for approval from approvers","status":"PENDING_APPROVAL"}`,
+			output: "Failed to read the approval store, requesting a new approval: invalid approval store %s: invalid character 'a' looking for beginning of value\n" +
+				"Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:        "approval with an invalid time",
+			store:       `{"schemaVersion":1,"approvalId":"a-4","decision":"APPROVED","approver":"jane","respondedOn":"2026-10-18 10:00","approvalKey":"sha256:4f53"}` + "\n",
+			reuseWindow: "3h",
+			status:      `{"message":"Waiting for approval from approvers","status":"PENDING_APPROVAL"}`,
+			output: "Approval request a-4 cannot be reused, invalid respondedOn '2026-10-18 10:00': parsing time \"2026-10-18 10:00\" as \"2006-01-02T15:04:05Z07:00\": cannot parse \" 10:00\" as \"T\"\n" +
+				"Waiting for approval from one of the following: testUserName\n",
+		},
+		{
+			name:        "invalid window",
+			store:       approvalStoreRecords,
+			reuseWindow: "-3h",
+			status:      `{"message":"Failed to initialize workflow manual approval request: 'invalid REUSE_WINDOW value '-3h': must be positive'","status":"FAILED"}`,
+			err:         "invalid REUSE_WINDOW value '-3h': must be positive",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			store := filepath.Join(dir, "approvals.jsonl")
+			if tt.store != "" {
+				require.NoError(t, os.WriteFile(store, []byte(tt.store), 0644))
+			}
+			backend := &fakeBackend{}
+			var testOutput strings.Builder
+			c := Config{
+				Handler:       "init",
+				ApprovalKey:   imageDigest,
+				ReuseWindow:   tt.reuseWindow,
+				ApprovalStore: store,
+				DistinctFrom:  tt.distinctFrom,
+				Backend:       backend,
+				OutputsDir:    dir,
+				StatusFile:    filepath.Join(dir, "status"),
+				clock:         func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) },
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						testOutput.WriteString(fmt.Sprintf(format, a...))
+					},
+				},
+			}
+
+			// Run
+			err := c.init()
+
+			// Verify
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, strings.ReplaceAll(tt.output, "%s", store), testOutput.String())
+			requireStatusFile(t, tt.status, c.StatusFile)
+			reused, err := os.ReadFile(filepath.Join(dir, "reusedApprovalId"))
+			if tt.reused == "" {
+				require.ErrorIs(t, err, os.ErrNotExist)
+				if tt.err == "" {
+					require.Len(t, backend.created, 1)
+				}
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.reused, string(reused))
+			require.Empty(t, backend.created)
+			record, err := os.ReadFile(filepath.Join(dir, "decisionRecord"))
+			require.NoError(t, err)
+			require.Contains(t, approvalStoreRecords, string(record))
+		})
+	}
+}
+
+func Test_callbackSaveApproval(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		saved   string
+	}{
+		{
+			name:    "approval is saved",
+			payload: `{"id":"a-5","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"jane","userId":"42"}`,
+			saved:   `{"schemaVersion":1,"approvalId":"a-5","decision":"APPROVED","approver":"jane","approverId":"42","respondedOn":"2026-10-18T12:30:00Z","approvalKey":"sha256:4f53","savedOn":"2026-10-18T12:31:00Z"}` + "\n",
+		},
+		{
+			name:    "rejection is not saved",
+			payload: `{"id":"a-5","status":"UPDATE_MANUAL_APPROVAL_STATUS_REJECTED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"jane","userId":"42"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			store := filepath.Join(dir, "approvals.jsonl")
+			c := Config{
+				Handler:       "callback",
+				Payload:       tt.payload,
+				ApprovalKey:   imageDigest,
+				ApprovalStore: store,
+				Backend:       &fakeBackend{},
+				OutputsDir:    dir,
+				StatusFile:    filepath.Join(dir, "status"),
+				clock:         func() time.Time { return time.Date(2026, 10, 18, 12, 31, 0, 0, time.UTC) },
+				Output: &MockStdOut{
+					MockPrintf:  func(format string, a ...any) {},
+					MockPrintln: func(a ...any) {},
+				},
+			}
+
+			// Run
+			err := c.callback()
+
+			// Verify
+			require.NoError(t, err)
+			saved, err := os.ReadFile(store)
+			if tt.saved == "" {
+				require.ErrorIs(t, err, os.ErrNotExist)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.saved, string(saved))
+		})
+	}
+}
+
+func Test_httpStore(t *testing.T) {
+	var requests []string
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, fmt.Sprintf("%s %s %s %s", r.Method, r.URL.RequestURI(), r.Header.Get("Authorization"), body))
+		switch r.URL.Query().Get("approvalKey") {
+		case imageDigest:
+			_, _ = io.WriteString(w, `[{"schemaVersion":1,"approvalId":"a-1","decision":"APPROVED","approver":"jane","approvalKey":"sha256:4f53"}]`)
+		case "sha256:0000":
+			w.WriteHeader(http.StatusNotFound)
+		case "sha256:ffff":
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	}))
+	defer platform.Close()
+	c := &Config{ApprovalKey: imageDigest, ApprovalStore: platform.URL + "/approvals?team=release", ApprovalStoreToken: "secret", Client: &http.Client{}}
+	policy, err := c.reusePolicy()
+	require.NoError(t, err)
+
+	// Run
+	records, err := policy.store.find(c.ctx(), imageDigest)
+	require.NoError(t, err)
+	require.Equal(t, []DecisionRecord{{SchemaVersion: 1, ApprovalID: "a-1", Decision: "APPROVED", Approver: "jane", ApprovalKey: imageDigest}}, records)
+
+	records, err = policy.store.find(c.ctx(), "sha256:0000")
+	require.NoError(t, err)
+	require.Empty(t, records)
+
+	_, err = policy.store.find(c.ctx(), "sha256:ffff")
+	require.EqualError(t, err, fmt.Sprintf("GET %s/approvals?team=release returned 500 Internal Server Error", platform.URL))
+
+	err = policy.store.save(c.ctx(), DecisionRecord{SchemaVersion: 1, ApprovalID: "a-2", Decision: "APPROVED", Approver: "joe", ApprovalKey: imageDigest})
+	require.NoError(t, err)
+
+	// Verify
+	require.Equal(t, []string{
+		"GET /approvals?team=release&approvalKey=sha256%3A4f53 Bearer secret ",
+		"GET /approvals?team=release&approvalKey=sha256%3A0000 Bearer secret ",
+		"GET /approvals?team=release&approvalKey=sha256%3Affff Bearer secret ",
+		`POST /approvals?team=release Bearer secret {"schemaVersion":1,"approvalId":"a-2","decision":"APPROVED","approver":"joe","approvalKey":"sha256:4f53"}`,
+	}, requests)
+}
@@ -0,0 +1,155 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// StatusSchemaVersion is the version of the status document, raised when fields change meaning or
+// are removed. New fields may be added without changing it.
+const StatusSchemaVersion = 1
+
+// maxStatusErrorBody is the length the API error body is truncated to in the status document
+const maxStatusErrorBody = 512
+
+// StatusDocument is written to the status file. Status and message are always present, so readers
+// of the original {status, message} document keep working.
+type StatusDocument struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Status        string `json:"status"`
+	Message       string `json:"message"`
+	Handler       string `json:"handler,omitempty"`
+	ApprovalID    string `json:"approvalId,omitempty"`
+
+	// Decision, Approver, RespondedOn and the ReasonCode of a rejection are set once the approver has
+	// answered
+	Decision    string `json:"decision,omitempty"`
+	Approver    string `json:"approver,omitempty"`
+	RespondedOn string `json:"respondedOn,omitempty"`
+	ReasonCode  string `json:"reasonCode,omitempty"`
+
+	// RequestedOn is when the approval was requested, ElapsedSeconds the time from then until the
+	// response or until the status was written
+	RequestedOn    string `json:"requestedOn,omitempty"`
+	UpdatedOn      string `json:"updatedOn"`
+	ElapsedSeconds *int64 `json:"elapsedSeconds,omitempty"`
+
+	// ErrorClass and APIError describe why the job failed
+	ErrorClass string          `json:"errorClass,omitempty"`
+	APIError   *StatusAPIError `json:"apiError,omitempty"`
+}
+
+// StatusAPIError is the platform API error of a failed job
+type StatusAPIError struct {
+	StatusCode int    `json:"statusCode"`
+	Path       string `json:"path,omitempty"`
+	RequestID  string `json:"requestId,omitempty"`
+	Code       string `json:"code,omitempty"`
+	Message    string `json:"message,omitempty"`
+	Body       string `json:"body,omitempty"`
+}
+
+// statusDetails are collected while the handler runs and written with the next status
+type statusDetails struct {
+	approvalID  string
+	decision    string
+	approver    string
+	approverID  string
+	respondedOn string
+	reasonCode  string
+	requestedOn time.Time
+}
+
+// readRequestedOn reads when the approval request was created, for the status of the handlers that did
+// not create it. The status is written without the request time when the request cannot be read.
+func (k *Config) readRequestedOn(id string) {
+	approval, err := k.getApproval(id)
+	if err != nil {
+		debugf("Failed to read approval request %s: '%s'\n", id, err)
+		return
+	}
+	k.statusDetails.requestedOn = approval.CreatedOn
+}
+
+func (k *Config) writeStatus(status string, message string) error {
+	statusFile := valueOrEnv(k.StatusFile, "CLOUDBEES_STATUS")
+	if statusFile == "" {
+		return configErrorf("--status-file flag or CLOUDBEES_STATUS environment variable missing")
+	}
+	output := k.statusDocument(status, message)
+
+	outputBytes, err := json.Marshal(&output)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(statusFile, outputBytes, 0666)
+	if err != nil {
+		return fmt.Errorf("failed to write to %s: %w", statusFile, err)
+	}
+	return nil
+}
+
+// writeErrorStatus writes the FAILED status with the class of the error and the API error details
+func (k *Config) writeErrorStatus(message string, err error) error {
+	k.statusError = err
+	return k.writeStatus("FAILED", message)
+}
+
+func (k *Config) statusDocument(status string, message string) *StatusDocument {
+	now := k.now().UTC()
+	details := k.statusDetails
+	doc := &StatusDocument{
+		SchemaVersion: StatusSchemaVersion,
+		Status:        status,
+		Message:       message,
+		Handler:       k.Handler,
+		ApprovalID:    details.approvalID,
+		Decision:      details.decision,
+		Approver:      details.approver,
+		RespondedOn:   details.respondedOn,
+		ReasonCode:    details.reasonCode,
+		UpdatedOn:     now.Format(time.RFC3339),
+	}
+
+	if !details.requestedOn.IsZero() {
+		doc.RequestedOn = details.requestedOn.UTC().Format(time.RFC3339)
+		end := now
+		if respondedOn, err := time.Parse(time.RFC3339, details.respondedOn); err == nil {
+			end = respondedOn
+		}
+		elapsed := int64(end.Sub(details.requestedOn).Seconds())
+		doc.ElapsedSeconds = &elapsed
+	}
+
+	if k.statusError != nil {
+		doc.ErrorClass = errorClass(k.statusError)
+		var apiErr *APIError
+		if errors.As(k.statusError, &apiErr) {
+			doc.APIError = &StatusAPIError{
+				StatusCode: apiErr.StatusCode,
+				Path:       apiErr.Path,
+				RequestID:  apiErr.RequestID,
+				Code:       apiErr.Code,
+				Message:    apiErr.Message,
+				Body:       truncate(apiErr.Body, maxStatusErrorBody),
+			}
+		}
+	}
+	return doc
+}
+
+// truncate shortens the value to at most max bytes without splitting a UTF-8 character
+func truncate(value string, max int) string {
+	if len(value) <= max {
+		return value
+	}
+	const suffix = "... (truncated)"
+	cut := max - len(suffix)
+	for cut > 0 && value[cut]&0xC0 == 0x80 {
+		cut--
+	}
+	return value[:cut] + suffix
+}
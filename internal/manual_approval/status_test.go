@@ -0,0 +1,143 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update the golden files")
+
+// requireStatusFile compares the status and message of the status file, the fields every reader of
+// the status document relies on
+func requireStatusFile(t *testing.T, expected string, statusFile string) {
+	t.Helper()
+	out, err := os.ReadFile(statusFile)
+	require.NoError(t, err)
+
+	var doc struct {
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(out, &doc))
+	actual, err := json.Marshal(doc)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(actual))
+}
+
+func Test_statusDocument(t *testing.T) {
+	longMessage := strings.Repeat("approvers must be members of the organization ", 20)
+	platform := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Request-Id", "req-123")
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = fmt.Fprintf(w, `{"code":"INVALID_ARGUMENT","message":%q}`, longMessage)
+	}))
+	defer platform.Close()
+	t.Setenv("API_TOKEN", "test")
+
+	tests := []struct {
+		name      string
+		config    Config
+		run       func(c *Config) error
+		approvals map[string]*ApprovalRequest
+		elapse    time.Duration
+	}{
+		{
+			name:   "init",
+			config: Config{Handler: "init"},
+			run:    (*Config).init,
+		},
+		{
+			name:   "init-api-error",
+			config: Config{Handler: "init", URL: platform.URL},
+			run:    (*Config).init,
+		},
+		{
+			name:   "callback-approved",
+			config: Config{Handler: "callback", Payload: `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_APPROVED","comments":"lgtm","respondedOn":"2026-10-18T12:30:00Z","userName":"jane"}`},
+			run:    (*Config).callback,
+			approvals: map[string]*ApprovalRequest{
+				"a-1": {ID: "a-1", Status: ApprovalStatusPending, CreatedOn: time.Date(2026, 10, 18, 12, 10, 0, 0, time.UTC)},
+			},
+		},
+		{
+			name:   "callback-unexpected-status",
+			config: Config{Handler: "callback", Payload: `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_UNSPECIFIED","comments":"","respondedOn":"2026-10-18T12:30:00Z","userName":"jane"}`},
+			run:    (*Config).callback,
+		},
+		{
+			name:   "wait-rejected",
+			config: Config{Handler: "wait"},
+			run:    (*Config).wait,
+			approvals: map[string]*ApprovalRequest{
+				"a-1": {ID: "a-1", Status: ApprovalStatusRejected, UserName: "jane", RespondedOn: "2026-10-18T12:45:30Z", Comments: "not now"},
+			},
+		},
+		{
+			name:      "wait-timed-out",
+			config:    Config{Handler: "wait", Timeout: time.Minute},
+			run:       (*Config).wait,
+			approvals: map[string]*ApprovalRequest{"a-1": {ID: "a-1", Status: ApprovalStatusPending}},
+			elapse:    time.Minute,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			dir := t.TempDir()
+			c := tt.config
+			if c.URL == "" {
+				c.Backend = &fakeBackend{approvals: tt.approvals}
+			}
+			c.OutputsDir = dir
+			c.StatusFile = filepath.Join(dir, "status")
+			c.PollInterval = time.Millisecond
+			now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+			c.clock = func() time.Time {
+				current := now
+				now = now.Add(tt.elapse)
+				return current
+			}
+			c.Output = &MockStdOut{
+				MockPrintf:  func(format string, a ...any) {},
+				MockPrintln: func(a ...any) {},
+			}
+
+			// Run
+			_ = tt.run(&c)
+
+			// Verify
+			out, err := os.ReadFile(c.StatusFile)
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", "status", tt.name+".json")
+			if *update {
+				var indented strings.Builder
+				doc := map[string]interface{}{}
+				require.NoError(t, json.Unmarshal(out, &doc))
+				encoder := json.NewEncoder(&indented)
+				encoder.SetIndent("", "  ")
+				require.NoError(t, encoder.Encode(doc))
+				require.NoError(t, os.WriteFile(golden, []byte(indented.String()), 0644))
+			}
+			expected, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), string(out))
+		})
+	}
+}
+
+func Test_truncate(t *testing.T) {
+	require.Equal(t, "short", truncate("short", 20))
+	require.Equal(t, "abcde... (truncated)", truncate("abcdefghijklmnopqrstuvwxyz", 20))
+	require.Equal(t, "ab... (truncated)", truncate("abäääääääääääääääää", 18))
+}
@@ -0,0 +1,185 @@
+package manual_approval
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// tokenExpiryMargin is how long before its expiry a cached OAuth2 token is replaced
+const tokenExpiryMargin = 30 * time.Second
+
+// TokenSource provides the bearer token of platform API requests
+type TokenSource interface {
+	// Token returns the current token
+	Token(ctx context.Context) (string, error)
+
+	// Invalidate drops a cached token after the platform API rejected it, the next Token call
+	// returns a fresh one
+	Invalidate()
+}
+
+// tokenSource returns the configured token source. An OAuth2 client credentials exchange is used
+// when a token URL is configured, then the token file, then the API_TOKEN environment variable.
+func (k *Config) tokenSource() (TokenSource, error) {
+	if k.TokenSource != nil {
+		return k.TokenSource, nil
+	}
+
+	if tokenURL := valueOrEnv(k.OAuthTokenURL, "OAUTH_TOKEN_URL"); tokenURL != "" {
+		clientID := valueOrEnv(k.OAuthClientID, "OAUTH_CLIENT_ID")
+		if clientID == "" {
+			return nil, configErrorf("--oauth-client-id flag or OAUTH_CLIENT_ID environment variable missing")
+		}
+		clientSecret := valueOrEnv(k.OAuthClientSecret, "OAUTH_CLIENT_SECRET")
+		if clientSecret == "" {
+			return nil, configErrorf("OAUTH_CLIENT_SECRET environment variable missing")
+		}
+		client, err := k.httpClient()
+		if err != nil {
+			return nil, err
+		}
+		k.TokenSource = &clientCredentialsTokenSource{
+			tokenURL:     tokenURL,
+			clientID:     clientID,
+			clientSecret: clientSecret,
+			scopes:       valueOrEnv(k.OAuthScopes, "OAUTH_SCOPES"),
+			client:       client,
+			now:          k.now,
+		}
+	} else if tokenFile := valueOrEnv(k.TokenFile, "API_TOKEN_FILE"); tokenFile != "" {
+		k.TokenSource = &fileTokenSource{path: tokenFile}
+	} else {
+		k.TokenSource = &envTokenSource{key: "API_TOKEN"}
+	}
+	return k.TokenSource, nil
+}
+
+// envTokenSource reads the token from an environment variable
+type envTokenSource struct {
+	key string
+}
+
+func (s *envTokenSource) Token(context.Context) (string, error) {
+	token := os.Getenv(s.key)
+	if token == "" {
+		return "", configErrorf("--token-file flag or %s environment variable missing", s.key)
+	}
+	return token, nil
+}
+
+func (s *envTokenSource) Invalidate() {}
+
+// fileTokenSource reads the token from a file on every request, so mounted secrets can be rotated
+// while the job is running
+type fileTokenSource struct {
+	path string
+}
+
+func (s *fileTokenSource) Token(context.Context) (string, error) {
+	data, err := os.ReadFile(s.path)
+	if err != nil {
+		return "", configErrorf("failed to read API token file: %w", err)
+	}
+	token := strings.TrimSpace(string(data))
+	if token == "" {
+		return "", configErrorf("API token file %s is empty", s.path)
+	}
+	return token, nil
+}
+
+func (s *fileTokenSource) Invalidate() {}
+
+// clientCredentialsTokenSource exchanges OAuth2 client credentials for an access token and caches
+// it until shortly before it expires
+type clientCredentialsTokenSource struct {
+	tokenURL     string
+	clientID     string
+	clientSecret string
+	scopes       string
+	client       HttpClient
+	now          func() time.Time
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time
+}
+
+type tokenResponse struct {
+	AccessToken      string `json:"access_token"`
+	TokenType        string `json:"token_type"`
+	ExpiresIn        int64  `json:"expires_in"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+func (s *clientCredentialsTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && (s.expiry.IsZero() || s.now().Before(s.expiry.Add(-tokenExpiryMargin))) {
+		return s.token, nil
+	}
+	debugf("Request OAuth2 token from '%s'\n", s.tokenURL)
+
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if s.scopes != "" {
+		form.Set("scope", s.scopes)
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", s.tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.SetBasicAuth(url.QueryEscape(s.clientID), url.QueryEscape(s.clientSecret))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OAuth2 token: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to get OAuth2 token: %w", err)
+	}
+
+	parsed := tokenResponse{}
+	_ = json.Unmarshal(body, &parsed)
+	if resp.StatusCode != http.StatusOK {
+		message := parsed.Error
+		if parsed.ErrorDescription != "" {
+			message += ": " + parsed.ErrorDescription
+		}
+		if message == "" {
+			message = strings.TrimSpace(string(body))
+		}
+		return "", fmt.Errorf("failed to get OAuth2 token: HTTP/%d %s: %s", resp.StatusCode, http.StatusText(resp.StatusCode), message)
+	}
+	if parsed.AccessToken == "" {
+		return "", fmt.Errorf("failed to get OAuth2 token: access_token missing in the response")
+	}
+	if parsed.TokenType != "" && !strings.EqualFold(parsed.TokenType, "bearer") {
+		return "", fmt.Errorf("failed to get OAuth2 token: unsupported token type %s", parsed.TokenType)
+	}
+
+	s.token = parsed.AccessToken
+	s.expiry = time.Time{}
+	if parsed.ExpiresIn > 0 {
+		s.expiry = s.now().Add(time.Duration(parsed.ExpiresIn) * time.Second)
+	}
+	return s.token, nil
+}
+
+func (s *clientCredentialsTokenSource) Invalidate() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.token = ""
+}
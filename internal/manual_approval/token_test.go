@@ -0,0 +1,256 @@
+package manual_approval
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// tokenServer is a local OAuth2 token endpoint issuing numbered tokens
+type tokenServer struct {
+	*httptest.Server
+	mu        sync.Mutex
+	issued    int
+	expiresIn int
+	forms     []string
+}
+
+func newTokenServer(t *testing.T, expiresIn int) *tokenServer {
+	s := &tokenServer{expiresIn: expiresIn}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		id, secret, ok := r.BasicAuth()
+		if !ok || id != "client" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client","error_description":"client authentication failed"}`))
+			return
+		}
+		if r.URL.Path == "/missing" {
+			_, _ = w.Write([]byte(`{"token_type":"Bearer"}`))
+			return
+		}
+		require.NoError(t, r.ParseForm())
+		s.forms = append(s.forms, r.PostForm.Encode())
+
+		s.issued++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = fmt.Fprintf(w, `{"access_token":"token-%d","token_type":"Bearer","expires_in":%d}`, s.issued, s.expiresIn)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func Test_clientCredentialsTokenSource(t *testing.T) {
+	// Prepare
+	server := newTokenServer(t, 300)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC)
+	c := &Config{
+		OAuthTokenURL:     server.URL,
+		OAuthClientID:     "client",
+		OAuthClientSecret: "s3cret",
+		OAuthScopes:       "approvals:write",
+		clock:             func() time.Time { return now },
+	}
+	source, err := c.tokenSource()
+	require.NoError(t, err)
+
+	// Run and verify
+	token, err := source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	// Cached until shortly before expiry
+	now = now.Add(4 * time.Minute)
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", token)
+
+	// Refreshed before expiry
+	now = now.Add(31 * time.Second)
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-2", token)
+
+	// Refreshed when invalidated
+	source.Invalidate()
+	token, err = source.Token(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-3", token)
+
+	require.Equal(t, []string{
+		"grant_type=client_credentials&scope=approvals%3Awrite",
+		"grant_type=client_credentials&scope=approvals%3Awrite",
+		"grant_type=client_credentials&scope=approvals%3Awrite",
+	}, server.forms)
+}
+
+func Test_tokenSource(t *testing.T) {
+	server := newTokenServer(t, 300)
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("file-token\n"), 0600))
+
+	tests := []struct {
+		name   string
+		config Config
+		env    map[string]string
+		token  string
+		err    string
+	}{
+		{
+			name:  "environment variable",
+			env:   map[string]string{"API_TOKEN": "env-token"},
+			token: "env-token",
+		},
+		{
+			name:  "token file",
+			env:   map[string]string{"API_TOKEN": "env-token", "API_TOKEN_FILE": tokenFile},
+			token: "file-token",
+		},
+		{
+			name:  "OAuth2 from the environment",
+			env:   map[string]string{"API_TOKEN": "env-token", "OAUTH_TOKEN_URL": server.URL, "OAUTH_CLIENT_ID": "client", "OAUTH_CLIENT_SECRET": "s3cret"},
+			token: "token-1",
+		},
+		{
+			name:   "OAuth2 without client ID",
+			config: Config{OAuthTokenURL: server.URL},
+			err:    "--oauth-client-id flag or OAUTH_CLIENT_ID environment variable missing",
+		},
+		{
+			name:   "OAuth2 without client secret",
+			config: Config{OAuthTokenURL: server.URL, OAuthClientID: "client"},
+			err:    "OAUTH_CLIENT_SECRET environment variable missing",
+		},
+		{
+			name:   "OAuth2 with wrong credentials",
+			config: Config{OAuthTokenURL: server.URL, OAuthClientID: "client", OAuthClientSecret: "wrong"},
+			err:    "failed to get OAuth2 token: HTTP/401 Unauthorized: invalid_client: client authentication failed",
+		},
+		{
+			name:   "OAuth2 endpoint without token",
+			config: Config{OAuthTokenURL: server.URL + "/missing", OAuthClientID: "client", OAuthClientSecret: "s3cret"},
+			err:    "failed to get OAuth2 token: access_token missing in the response",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			server.issued = 0
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			// Run
+			token := ""
+			source, err := tt.config.tokenSource()
+			if err == nil {
+				token, err = source.Token(context.Background())
+			}
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+				require.Equal(t, tt.token, token)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+		})
+	}
+}
+
+func Test_sendRefreshesTokenOnUnauthorized(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+
+	tests := []struct {
+		name         string
+		config       func(tokenServerURL string) Config
+		rotate       bool
+		accepted     string
+		tokens       []string
+		unauthorized bool
+	}{
+		{
+			name: "OAuth2 token refreshed",
+			config: func(tokenServerURL string) Config {
+				return Config{OAuthTokenURL: tokenServerURL, OAuthClientID: "client", OAuthClientSecret: "s3cret"}
+			},
+			accepted: "token-2",
+			tokens:   []string{"token-1", "token-2"},
+		},
+		{
+			name: "rotated token file re-read",
+			config: func(string) Config {
+				return Config{TokenFile: tokenFile}
+			},
+			rotate:   true,
+			accepted: "rotated",
+			tokens:   []string{"old", "rotated"},
+		},
+		{
+			name: "unchanged token not retried",
+			config: func(string) Config {
+				return Config{TokenFile: tokenFile}
+			},
+			accepted:     "rotated",
+			tokens:       []string{"old"},
+			unauthorized: true,
+		},
+		{
+			name: "refreshed token rejected again",
+			config: func(tokenServerURL string) Config {
+				return Config{OAuthTokenURL: tokenServerURL, OAuthClientID: "client", OAuthClientSecret: "s3cret"}
+			},
+			accepted:     "token-3",
+			tokens:       []string{"token-1", "token-2"},
+			unauthorized: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			tokenServer := newTokenServer(t, 3600)
+			require.NoError(t, os.WriteFile(tokenFile, []byte("old"), 0600))
+
+			var tokens []string
+			api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				token := r.Header.Get("Authorization")[len("Bearer "):]
+				tokens = append(tokens, token)
+				if token != tt.accepted {
+					if tt.rotate {
+						require.NoError(t, os.WriteFile(tokenFile, []byte("rotated"), 0600))
+					}
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				_, _ = w.Write([]byte(`{"id":"a-1","status":"PENDING_APPROVAL"}`))
+			}))
+			defer api.Close()
+
+			// Run
+			c := tt.config(tokenServer.URL)
+			c.URL = api.URL
+			resp, err := c.get(context.Background(), "/v1/workflows/approval/a-1", nil)
+
+			// Verify
+			if !tt.unauthorized {
+				require.NoError(t, err)
+				require.Equal(t, `{"id":"a-1","status":"PENDING_APPROVAL"}`, resp)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, fmt.Sprintf("failed to send event: \nGET %s/v1/workflows/approval/a-1\nHTTP/401 401 Unauthorized\n", api.URL), err.Error())
+			}
+			require.Equal(t, tt.tokens, tokens)
+		})
+	}
+}
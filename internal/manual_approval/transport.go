@@ -0,0 +1,131 @@
+package manual_approval
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultConnectTimeout      = 30 * time.Second
+	defaultTLSHandshakeTimeout = 10 * time.Second
+	defaultRequestTimeout      = 150 * time.Second
+)
+
+// RealHttpClient sends the platform API requests with its own http.Client, so no process wide
+// defaults are changed
+type RealHttpClient struct {
+	client *http.Client
+}
+
+func (c *RealHttpClient) Do(req *http.Request) (*http.Response, error) {
+	return c.client.Do(req)
+}
+
+// NewHttpClient builds the platform API client from the transport settings of the configuration
+func NewHttpClient(k *Config) (*RealHttpClient, error) {
+	tlsConfig, err := k.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	proxy, err := k.proxy()
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{
+		Timeout:   durationOr(k.ConnectTimeout, defaultConnectTimeout),
+		KeepAlive: 30 * time.Second,
+	}
+	transport := &http.Transport{
+		Proxy:                 proxy,
+		DialContext:           dialer.DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   durationOr(k.TLSHandshakeTimeout, defaultTLSHandshakeTimeout),
+		ForceAttemptHTTP2:     !k.DisableHTTP2,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       90 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+	if k.DisableHTTP2 {
+		// A non-nil empty map keeps the transport from upgrading TLS connections to HTTP/2
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &RealHttpClient{
+		client: &http.Client{
+			Transport: transport,
+			Timeout:   durationOr(k.RequestTimeout, defaultRequestTimeout),
+		},
+	}, nil
+}
+
+// tlsConfig trusts the CA bundle in addition to the system roots and presents the client
+// certificate for mTLS when they are configured
+func (k *Config) tlsConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if caFile := valueOrEnv(k.CAFile, "CA_FILE"); caFile != "" {
+		pem, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, configErrorf("failed to read CA file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, configErrorf("no certificates found in CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	certFile := valueOrEnv(k.ClientCertFile, "CLIENT_CERT_FILE")
+	keyFile := valueOrEnv(k.ClientKeyFile, "CLIENT_KEY_FILE")
+	if (certFile == "") != (keyFile == "") {
+		return nil, configErrorf("client certificate and key must be given together")
+	}
+	if certFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, configErrorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// proxy returns the proxy selection of the transport. The proxy and NO_PROXY settings fall back to
+// the standard HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables.
+func (k *Config) proxy() (func(*http.Request) (*url.URL, error), error) {
+	proxyConfig := httpproxy.FromEnvironment()
+	if k.Proxy != "" {
+		if _, err := url.Parse(k.Proxy); err != nil {
+			return nil, configErrorf("invalid proxy URL: %w", err)
+		}
+		proxyConfig.HTTPProxy = k.Proxy
+		proxyConfig.HTTPSProxy = k.Proxy
+	}
+	if k.NoProxy != "" {
+		proxyConfig.NoProxy = k.NoProxy
+	}
+
+	proxyFunc := proxyConfig.ProxyFunc()
+	return func(req *http.Request) (*url.URL, error) {
+		return proxyFunc(req.URL)
+	}, nil
+}
+
+func durationOr(value time.Duration, fallback time.Duration) time.Duration {
+	if value > 0 {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,318 @@
+package manual_approval
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testCertificate is a certificate with its key, signed by the parent or self-signed without one
+type testCertificate struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+func newTestCertificate(t *testing.T, template *x509.Certificate, parent *testCertificate) *testCertificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template.SerialNumber = big.NewInt(time.Now().UnixNano())
+	template.NotBefore = time.Now().Add(-time.Hour)
+	template.NotAfter = time.Now().Add(time.Hour)
+	signer, signerKey := template, key
+	if parent != nil {
+		signer, signerKey = parent.cert, parent.key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCertificate{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCertificate) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	require.NoError(t, err)
+	return cert
+}
+
+func writeTestFile(t *testing.T, dir string, name string, content []byte) string {
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, content, 0600))
+	return path
+}
+
+func Test_NewHttpClientTLS(t *testing.T) {
+	ca := newTestCertificate(t, &x509.Certificate{
+		Subject:               pkix.Name{CommonName: "test CA"},
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}, nil)
+	serverCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "localhost"},
+		IPAddresses: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}, ca)
+	clientCert := newTestCertificate(t, &x509.Certificate{
+		Subject:     pkix.Name{CommonName: "manual-approval"},
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca)
+
+	dir := t.TempDir()
+	caFile := writeTestFile(t, dir, "ca.pem", ca.certPEM)
+	certFile := writeTestFile(t, dir, "client.pem", clientCert.certPEM)
+	keyFile := writeTestFile(t, dir, "client-key.pem", clientCert.keyPEM)
+	notPEMFile := writeTestFile(t, dir, "not.pem", []byte("not a certificate"))
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(ca.cert)
+
+	tests := []struct {
+		name       string
+		config     Config
+		env        map[string]string
+		clientAuth tls.ClientAuthType
+		http2      bool
+		proto      string
+		err        string
+		requestErr string
+	}{
+		{
+			name:   "custom CA",
+			config: Config{CAFile: caFile},
+			proto:  "HTTP/1.1",
+		},
+		{
+			name:  "custom CA from the environment",
+			env:   map[string]string{"CA_FILE": caFile},
+			proto: "HTTP/1.1",
+		},
+		{
+			name:       "unknown CA",
+			requestErr: "certificate signed by unknown authority",
+		},
+		{
+			name:       "mTLS",
+			config:     Config{CAFile: caFile, ClientCertFile: certFile, ClientKeyFile: keyFile},
+			clientAuth: tls.RequireAndVerifyClientCert,
+			proto:      "HTTP/1.1",
+		},
+		{
+			name:       "mTLS without client certificate",
+			config:     Config{CAFile: caFile},
+			clientAuth: tls.RequireAndVerifyClientCert,
+			requestErr: "certificate required",
+		},
+		{
+			name:   "HTTP/2",
+			config: Config{CAFile: caFile},
+			http2:  true,
+			proto:  "HTTP/2.0",
+		},
+		{
+			name:   "HTTP/2 disabled",
+			config: Config{CAFile: caFile, DisableHTTP2: true},
+			http2:  true,
+			proto:  "HTTP/1.1",
+		},
+		{
+			name:   "missing CA file",
+			config: Config{CAFile: filepath.Join(dir, "missing.pem")},
+			err:    "failed to read CA file: open " + filepath.Join(dir, "missing.pem") + ": no such file or directory",
+		},
+		{
+			name:   "CA file without certificates",
+			config: Config{CAFile: notPEMFile},
+			err:    "no certificates found in CA file " + notPEMFile,
+		},
+		{
+			name:   "client certificate without key",
+			config: Config{ClientCertFile: certFile},
+			err:    "client certificate and key must be given together",
+		},
+		{
+			name:   "invalid client certificate",
+			config: Config{ClientCertFile: notPEMFile, ClientKeyFile: keyFile},
+			err:    "failed to load client certificate: tls: failed to find any PEM data in certificate input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+			server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(r.Proto))
+			}))
+			server.EnableHTTP2 = tt.http2
+			server.TLS = &tls.Config{
+				Certificates: []tls.Certificate{serverCert.tlsCertificate(t)},
+				ClientAuth:   tt.clientAuth,
+				ClientCAs:    clientCAs,
+			}
+			server.StartTLS()
+			defer server.Close()
+
+			// Run
+			client, err := NewHttpClient(&tt.config)
+
+			// Verify
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", server.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			if tt.requestErr != "" {
+				require.Error(t, err)
+				require.Contains(t, err.Error(), tt.requestErr)
+				return
+			}
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+			require.Equal(t, tt.proto, resp.Proto)
+		})
+	}
+}
+
+func Test_NewHttpClientProxy(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		env    map[string]string
+		url    string
+		proxy  string
+		err    string
+	}{
+		{
+			name:   "configured proxy",
+			config: Config{Proxy: "http://proxy.internal:3128"},
+			url:    "https://api.cloudbees.io/v1/workflows/approval",
+			proxy:  "http://proxy.internal:3128",
+		},
+		{
+			name:   "configured proxy and no proxy rule",
+			config: Config{Proxy: "http://proxy.internal:3128", NoProxy: "internal,.cloudbees.io"},
+			url:    "https://api.cloudbees.io/v1/workflows/approval",
+		},
+		{
+			name:  "proxy from the environment",
+			env:   map[string]string{"HTTPS_PROXY": "http://env-proxy.internal:3128", "NO_PROXY": "10.0.0.0/8"},
+			url:   "https://api.cloudbees.io/v1/workflows/approval",
+			proxy: "http://env-proxy.internal:3128",
+		},
+		{
+			name: "no proxy rule from the environment",
+			env:  map[string]string{"HTTPS_PROXY": "http://env-proxy.internal:3128", "NO_PROXY": "10.0.0.0/8"},
+			url:  "https://10.1.2.3/v1/workflows/approval",
+		},
+		{
+			name:   "configured proxy overrides the environment",
+			config: Config{Proxy: "http://proxy.internal:3128"},
+			env:    map[string]string{"HTTPS_PROXY": "http://env-proxy.internal:3128"},
+			url:    "https://api.cloudbees.io/v1/workflows/approval",
+			proxy:  "http://proxy.internal:3128",
+		},
+		{
+			name: "no proxy",
+			url:  "https://api.cloudbees.io/v1/workflows/approval",
+		},
+		{
+			name:   "invalid proxy",
+			config: Config{Proxy: "http://proxy internal:3128"},
+			err:    "invalid proxy URL: parse \"http://proxy internal:3128\": invalid character \" \" in host name",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY", "http_proxy", "https_proxy", "no_proxy", "REQUEST_METHOD"} {
+				t.Setenv(key, "")
+			}
+			for k, v := range tt.env {
+				t.Setenv(k, v)
+			}
+
+			// Run
+			client, err := NewHttpClient(&tt.config)
+
+			// Verify
+			if tt.err != "" {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
+			req, err := http.NewRequest("GET", tt.url, nil)
+			require.NoError(t, err)
+			proxy, err := client.client.Transport.(*http.Transport).Proxy(req)
+			require.NoError(t, err)
+			if tt.proxy == "" {
+				require.Nil(t, proxy)
+			} else {
+				require.Equal(t, tt.proxy, proxy.String())
+			}
+		})
+	}
+}
+
+func Test_NewHttpClientTimeouts(t *testing.T) {
+	// Prepare
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	// Run
+	client, err := NewHttpClient(&Config{ConnectTimeout: time.Second, TLSHandshakeTimeout: 2 * time.Second, RequestTimeout: 50 * time.Millisecond})
+	require.NoError(t, err)
+	req, err := http.NewRequest("GET", server.URL, nil)
+	require.NoError(t, err)
+	_, err = client.Do(req)
+
+	// Verify
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Client.Timeout exceeded")
+	require.Equal(t, 50*time.Millisecond, client.client.Timeout)
+	require.Equal(t, 2*time.Second, client.client.Transport.(*http.Transport).TLSHandshakeTimeout)
+
+	defaults, err := NewHttpClient(&Config{})
+	require.NoError(t, err)
+	require.Equal(t, defaultRequestTimeout, defaults.client.Timeout)
+	require.Equal(t, defaultTLSHandshakeTimeout, defaults.client.Transport.(*http.Transport).TLSHandshakeTimeout)
+	require.NotSame(t, http.DefaultClient, defaults.client)
+	require.Zero(t, http.DefaultClient.Timeout)
+}
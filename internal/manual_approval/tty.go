@@ -0,0 +1,309 @@
+package manual_approval
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	BackendPlatform = "platform"
+	BackendTTY      = "tty"
+)
+
+// ttyApprovalID identifies the single approval request of the tty backend
+const ttyApprovalID = "local"
+
+// ttyBackend asks for the approval on the terminal instead of the platform
+type ttyBackend struct {
+	k        *Config
+	schema   []ApprovalInput
+	approval *ApprovalRequest
+}
+
+func (b *ttyBackend) Create(_ context.Context, request map[string]interface{}) (*CreateManualApprovalResponse, error) {
+	debugf("Inside tty backend\n")
+
+	inputs, _ := request["approvalInputs"].(string)
+	schema, err := usableApprovalInputs(inputs)
+	if err != nil {
+		return nil, err
+	}
+	b.schema = schema
+	b.approval = &ApprovalRequest{ID: ttyApprovalID, Status: ApprovalStatusPending}
+
+	resp := &CreateManualApprovalResponse{ID: ttyApprovalID}
+	approvers, _ := request["approvers"].([]string)
+	if len(approvers) == 0 {
+		approvers = []string{"any eligible user"}
+	}
+	for _, approver := range approvers {
+		resp.Approvers = append(resp.Approvers, Approvers{UserName: approver})
+	}
+	return resp, nil
+}
+
+// Get asks for the inputs and the decision the first time it is called. It gives up waiting for an
+// answer when the context is cancelled.
+func (b *ttyBackend) Get(ctx context.Context, id string) (*ApprovalRequest, error) {
+	if b.approval == nil || id != ttyApprovalID {
+		return nil, fmt.Errorf("approval request %s not found", id)
+	}
+	if b.approval.Status != ApprovalStatusPending {
+		return b.approval, nil
+	}
+
+	in := b.k.Input
+	if in == nil {
+		in = os.Stdin
+	}
+	p := &prompter{ctx: ctx, out: b.k.Output, in: bufio.NewReader(in)}
+
+	// Inputs are asked after the inputs their conditions refer to and skipped when the conditions
+	// are not met
+	inputs := []interface{}{}
+	values := map[string]interface{}{}
+	for _, input := range conditionOrder(b.schema) {
+		if !applicableInputs(b.schema, values)[input.Name] {
+			continue
+		}
+		value, isDefault, ok, err := p.askInput(input)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			values[input.Name] = value
+			inputs = append(inputs, map[string]interface{}{"name": input.Name, "value": value, "is_default": isDefault})
+		}
+	}
+
+	policy, err := b.k.rejectionPolicy()
+	if err != nil {
+		return nil, err
+	}
+	approve, err := p.askDecision()
+	if err != nil {
+		return nil, err
+	}
+	var reasonCode string
+	if !approve && len(policy.reasons) > 0 {
+		reasonCode, err = p.askReasonCode(policy)
+		if err != nil {
+			return nil, err
+		}
+	}
+	comments, err := p.askComments(policy, approve)
+	if err != nil {
+		return nil, err
+	}
+
+	b.approval.Status = ApprovalStatusRejected
+	if approve {
+		b.approval.Status = ApprovalStatusApproved
+	}
+	b.approval.UserName = localUserName()
+	b.approval.RespondedOn = b.k.now().UTC().Format(time.RFC3339)
+	b.approval.Comments = comments
+	b.approval.ReasonCode = reasonCode
+	b.approval.Inputs = inputs
+	return b.approval, nil
+}
+
+// UpdateStatus has nothing to record, the decision is made on the terminal
+func (b *ttyBackend) UpdateStatus(context.Context, map[string]interface{}) error {
+	return nil
+}
+
+func (b *ttyBackend) Cancel(_ context.Context, _ string, status string) error {
+	if b.approval != nil {
+		b.approval.Status = status
+	}
+	return nil
+}
+
+func localUserName() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil && u.Username != "" {
+		return u.Username
+	}
+	return "local user"
+}
+
+// prompter reads answers from the terminal
+type prompter struct {
+	ctx context.Context
+	out StdOut
+	in  *bufio.Reader
+}
+
+// readLine reads a line in the background, so that a cancelled context stops the wait for it. The
+// read itself cannot be interrupted and ends with the process.
+func (p *prompter) readLine() (string, error) {
+	type result struct {
+		line string
+		err  error
+	}
+	read := make(chan result, 1)
+	go func() {
+		line, err := p.in.ReadString('\n')
+		read <- result{line, err}
+	}()
+
+	select {
+	case <-p.ctx.Done():
+		return "", p.ctx.Err()
+	case r := <-read:
+		return r.line, r.err
+	}
+}
+
+// ask prints the prompt and reads a single line answer
+func (p *prompter) ask(prompt string) (string, error) {
+	p.out.Printf("%s", prompt)
+	line, err := p.readLine()
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("no response: input closed")
+		}
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// askInput asks for the value of an approval input until a valid value is given. It reports whether
+// the default value is used and whether the input has a value at all.
+func (p *prompter) askInput(input ApprovalInput) (interface{}, bool, bool, error) {
+	if input.Description != "" {
+		p.out.Printf("%s\n", input.Description)
+	}
+	for i, option := range input.Options {
+		p.out.Printf("  %d) %s\n", i+1, option)
+	}
+
+	prompt := fmt.Sprintf("%s (%s", input.Name, input.Type)
+	if input.Required {
+		prompt += ", required"
+	}
+	prompt += ")"
+	if defaultValue, err := valueToString(input.Default); err == nil && input.Default != nil {
+		prompt += fmt.Sprintf(" [%s]", defaultValue)
+	}
+	prompt += ": "
+
+	for {
+		answer, err := p.ask(prompt)
+		if err != nil {
+			return nil, false, false, err
+		}
+		answer = strings.TrimSpace(answer)
+
+		if answer == "" {
+			switch {
+			case input.Default != nil:
+				return input.Default, true, true, nil
+			case input.Required:
+				p.out.Printf("A value is required\n")
+				continue
+			default:
+				return nil, false, false, nil
+			}
+		}
+
+		// Choices can be picked by their number
+		if index, err := strconv.Atoi(answer); err == nil && input.Type == InputTypeChoice && index >= 1 && index <= len(input.Options) {
+			answer = input.Options[index-1]
+		}
+		if input.Type == InputTypeMultiChoice && !strings.HasPrefix(answer, "[") {
+			picks := strings.Split(answer, ",")
+			for i, pick := range picks {
+				pick = strings.TrimSpace(pick)
+				if index, err := strconv.Atoi(pick); err == nil && index >= 1 && index <= len(input.Options) {
+					pick = input.Options[index-1]
+				}
+				picks[i] = pick
+			}
+			answer = strings.Join(picks, ",")
+		}
+		if input.Type == InputTypeBoolean {
+			switch strings.ToLower(answer) {
+			case "y", "yes":
+				answer = "true"
+			case "n", "no":
+				answer = "false"
+			}
+		}
+
+		value, err := input.parseValue(answer)
+		if err != nil {
+			p.out.Printf("Invalid value: %s\n", err)
+			continue
+		}
+		return value, false, true, nil
+	}
+}
+
+// askDecision asks whether to approve or reject until a valid answer is given
+func (p *prompter) askDecision() (bool, error) {
+	for {
+		answer, err := p.ask("Approve or reject? [a/r]: ")
+		if err != nil {
+			return false, err
+		}
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "a", "approve", "approved":
+			return true, nil
+		case "r", "reject", "rejected":
+			return false, nil
+		}
+		p.out.Printf("Please answer 'a' to approve or 'r' to reject\n")
+	}
+}
+
+// askReasonCode asks for one of the rejection reason codes of the policy, by code or by number
+func (p *prompter) askReasonCode(policy *rejectionPolicy) (string, error) {
+	for i, reason := range policy.reasons {
+		p.out.Printf("  %d) %s\n", i+1, reason)
+	}
+	for {
+		answer, err := p.ask("Reason code: ")
+		if err != nil {
+			return "", err
+		}
+		answer = strings.TrimSpace(answer)
+		if index, err := strconv.Atoi(answer); err == nil && index >= 1 && index <= len(policy.reasons) {
+			answer = policy.reasons[index-1]
+		}
+		problem := policy.checkReasonCode(answer)
+		if problem == "" {
+			return answer, nil
+		}
+		p.out.Printf("Not accepted: %s\n", problem)
+	}
+}
+
+// askComments asks for the comments until they meet the policy of rejections
+func (p *prompter) askComments(policy *rejectionPolicy, approve bool) (string, error) {
+	for {
+		comments, err := p.ask("Comments: ")
+		if err != nil {
+			return "", err
+		}
+		if approve {
+			return comments, nil
+		}
+		problem := policy.checkComment(comments)
+		if problem == "" {
+			return comments, nil
+		}
+		p.out.Printf("Not accepted: %s\n", problem)
+	}
+}
@@ -0,0 +1,223 @@
+package manual_approval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_waitTTY(t *testing.T) {
+	const inputs = "reason:\n  type: string\n  required: true\n  description: Why are we deploying?\nreplicas:\n  type: number\n  default: 3\nenv:\n  type: choice\n  options: [staging, production]\nnotify:\n  type: boolean"
+
+	tests := []struct {
+		name              string
+		inputs            string
+		answers           string
+		statusInFile      string
+		inputValsInOutput string
+		commentsInOutput  string
+		rejectionReasons  string
+		minCommentLength  string
+		reasonInOutput    string
+		output            string
+		exitCode          int
+		err               string
+	}{
+		{
+			name:              "approve with retries",
+			inputs:            inputs,
+			answers:           "\nhotfix\nmany\n\n3\n2\nyes\nmaybe\na\nlgtm\n",
+			statusInFile:      "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"APPROVED\"}",
+			inputValsInOutput: "{\"env\":\"production\",\"notify\":true,\"reason\":\"hotfix\",\"replicas\":3}",
+			commentsInOutput:  "lgtm",
+			output: "Waiting for approval from one of the following: user@mail.com\n" +
+				"Instructions:\nCheck the dashboard\n\n" +
+				"Why are we deploying?\n" +
+				"reason (string, required): A value is required\n" +
+				"reason (string, required): " +
+				"replicas (number) [3]: Invalid value: 'many' is not a number\n" +
+				"replicas (number) [3]: " +
+				"  1) staging\n  2) production\n" +
+				"env (choice): Invalid value: '3' is not one of the options: staging, production\n" +
+				"env (choice): " +
+				"notify (boolean): " +
+				"Approve or reject? [a/r]: Please answer 'a' to approve or 'r' to reject\n" +
+				"Approve or reject? [a/r]: " +
+				"Comments: " +
+				"Approved by tester on 2026-10-18T12:00:00Z with comments:\nlgtm\n" +
+				"\nInput Parameters:\n------------------\n reason   : hotfix\n replicas : 3 (default)\n env      : production\n notify   : true\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:              "reject without inputs",
+			answers:           "r\nnot now",
+			statusInFile:      "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"REJECTED\"}",
+			inputValsInOutput: "{}",
+			commentsInOutput:  "not now",
+			output: "Waiting for approval from one of the following: user@mail.com\n" +
+				"Instructions:\nCheck the dashboard\n\n" +
+				"Approve or reject? [a/r]: " +
+				"Comments: " +
+				"Rejected by tester on 2026-10-18T12:00:00Z with comments:\nnot now\n",
+			exitCode: ExitRejected,
+			err:      "approval request local was rejected by tester",
+		},
+		{
+			name:              "reject with reason code and comment policy",
+			answers:           "r\ncost\n1\nbad\nCVE-2026-1234 unpatched\n",
+			rejectionReasons:  "security,quality,timing",
+			minCommentLength:  "10",
+			statusInFile:      "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"REJECTED\"}",
+			inputValsInOutput: "{}",
+			commentsInOutput:  "CVE-2026-1234 unpatched",
+			reasonInOutput:    "security",
+			output: "Waiting for approval from one of the following: user@mail.com\n" +
+				"Instructions:\nCheck the dashboard\n\n" +
+				"Approve or reject? [a/r]: " +
+				"  1) security\n  2) quality\n  3) timing\n" +
+				"Reason code: Not accepted: unknown reason code 'cost', expected one of: security, quality, timing\n" +
+				"Reason code: " +
+				"Comments: Not accepted: the comment must be at least 10 characters long, got 3\n" +
+				"Comments: " +
+				"Rejected by tester on 2026-10-18T12:00:00Z with comments:\nCVE-2026-1234 unpatched\n" +
+				"Reason: security\n",
+			exitCode: ExitRejected,
+			err:      "approval request local was rejected by tester",
+		},
+		{
+			name:              "conditional inputs",
+			inputs:            "reason:\n  type: string\n  required: true\n  when: {action: rollback}\naction:\n  type: choice\n  options: [deploy, rollback]\ncanary:\n  type: boolean\n  when: {action: deploy}",
+			answers:           "2\noutage\na\n\n",
+			statusInFile:      "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"APPROVED\"}",
+			inputValsInOutput: "{\"action\":\"rollback\",\"reason\":\"outage\"}",
+			output: "Waiting for approval from one of the following: user@mail.com\n" +
+				"Instructions:\nCheck the dashboard\n\n" +
+				"  1) deploy\n  2) rollback\n" +
+				"action (choice): " +
+				"reason (string, required): " +
+				"Approve or reject? [a/r]: " +
+				"Comments: " +
+				"Approved by tester on 2026-10-18T12:00:00Z with comments:\n\n" +
+				"\nInput Parameters:\n------------------\n action : rollback\n reason : outage\n",
+			exitCode: ExitOK,
+		},
+		{
+			name:    "input closed",
+			inputs:  inputs,
+			answers: "hotfix\n",
+			output: "Waiting for approval from one of the following: user@mail.com\n" +
+				"Instructions:\nCheck the dashboard\n\n" +
+				"Why are we deploying?\n" +
+				"reason (string, required): " +
+				"replicas (number) [3]: ",
+			exitCode: ExitFailure,
+			err:      "no response: input closed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			t.Setenv("USER", "tester")
+			dir := t.TempDir()
+			var testOutput strings.Builder
+
+			// Run
+			c := Config{
+				BackendType:      BackendTTY,
+				Approvers:        "user@mail.com",
+				Instructions:     "Check the **dashboard**",
+				Inputs:           tt.inputs,
+				RejectionReasons: tt.rejectionReasons,
+				MinCommentLength: tt.minCommentLength,
+				OutputMode:       OutputModePlain,
+				OutputsDir:       dir,
+				StatusFile:       filepath.Join(dir, "status"),
+				Input:            strings.NewReader(tt.answers),
+				clock:            func() time.Time { return time.Date(2026, 10, 18, 12, 0, 0, 0, time.UTC) },
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						testOutput.WriteString(fmt.Sprintf(format, a...))
+					},
+				},
+			}
+			err := c.wait()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+			require.Equal(t, tt.exitCode, ExitCode(err))
+			require.Equal(t, tt.output, testOutput.String())
+
+			if tt.statusInFile != "" {
+				requireStatusFile(t, tt.statusInFile, filepath.Join(dir, "status"))
+			}
+			if tt.inputValsInOutput != "" {
+				out, ferr := os.ReadFile(filepath.Join(dir, "approvalInputValues"))
+				require.NoError(t, ferr)
+				require.Equal(t, tt.inputValsInOutput, string(out))
+			}
+			if tt.commentsInOutput != "" {
+				out, ferr := os.ReadFile(filepath.Join(dir, "comments"))
+				require.NoError(t, ferr)
+				require.Equal(t, tt.commentsInOutput, string(out))
+			}
+			if tt.reasonInOutput != "" {
+				out, ferr := os.ReadFile(filepath.Join(dir, "reasonCode"))
+				require.NoError(t, ferr)
+				require.Equal(t, tt.reasonInOutput, string(out))
+			}
+		})
+	}
+}
+
+func Test_waitTTYCancelled(t *testing.T) {
+	// Prepare
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	in, answers := io.Pipe()
+	defer answers.Close()
+	c := Config{
+		BackendType: BackendTTY,
+		Approvers:   "user@mail.com",
+		OutputMode:  OutputModePlain,
+		OutputsDir:  dir,
+		StatusFile:  filepath.Join(dir, "status"),
+		Context:     ctx,
+		Input:       in,
+		Output: &MockStdOut{
+			MockPrintf: func(format string, a ...any) {
+				// nobody answers, the run is interrupted at the prompt
+				if strings.HasPrefix(fmt.Sprintf(format, a...), "Approve or reject?") {
+					cancel()
+				}
+			},
+			MockPrintln: func(a ...any) {},
+		},
+	}
+
+	// Run
+	done := make(chan error, 1)
+	go func() { done <- c.wait() }()
+
+	// Verify
+	select {
+	case err := <-done:
+		require.EqualError(t, err, "approval request local: Workflow approval request was aborted")
+		require.Equal(t, ExitCancelled, ExitCode(err))
+	case <-time.After(5 * time.Second):
+		t.Fatal("wait did not stop when the context was cancelled")
+	}
+	requireStatusFile(t, `{"message":"Workflow approval request was aborted","status":"FAILED"}`, c.StatusFile)
+}
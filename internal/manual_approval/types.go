@@ -2,7 +2,9 @@ package manual_approval
 
 import (
 	"context"
+	"io"
 	"net/http"
+	"time"
 )
 
 type HttpClient interface {
@@ -19,11 +21,218 @@ type Config struct {
 	Client HttpClient
 	Output StdOut
 
+	// Backend stores the approval requests, it is selected with BackendType when it is not set
+	Backend ApprovalBackend
+
+	// Input is read by interactive backends, os.Stdin is used when it is not set
+	Input io.Reader
+
 	// Handler field allows you to handler.
 	Handler string `json:"handler,omitempty"`
+
+	// URL is the platform API URL, the URL environment variable is used when it is not set
+	URL string `json:"url,omitempty"`
+
+	// TokenFile is a file containing the platform API token, re-read for every request. The
+	// API_TOKEN_FILE and API_TOKEN environment variables are used when it is not set.
+	TokenFile string `json:"tokenFile,omitempty"`
+
+	// CAFile is a PEM bundle of certificate authorities trusted in addition to the system roots,
+	// falls back to the CA_FILE environment variable
+	CAFile string `json:"caFile,omitempty"`
+
+	// ClientCertFile and ClientKeyFile are the PEM client certificate and key presented for mTLS,
+	// they fall back to the CLIENT_CERT_FILE and CLIENT_KEY_FILE environment variables
+	ClientCertFile string `json:"clientCertFile,omitempty"`
+	ClientKeyFile  string `json:"clientKeyFile,omitempty"`
+
+	// Proxy is the URL of the proxy for platform API requests, the HTTPS_PROXY and HTTP_PROXY
+	// environment variables are used when it is not set
+	Proxy string `json:"proxy,omitempty"`
+
+	// NoProxy lists the hosts reached without the proxy, falls back to the NO_PROXY environment variable
+	NoProxy string `json:"noProxy,omitempty"`
+
+	// DisableHTTP2 limits platform API requests to HTTP/1.1
+	DisableHTTP2 bool `json:"disableHttp2,omitempty"`
+
+	// ConnectTimeout, TLSHandshakeTimeout and RequestTimeout limit establishing the connection, the
+	// TLS handshake and a whole platform API request including reading the response
+	ConnectTimeout      time.Duration `json:"connectTimeout,omitempty"`
+	TLSHandshakeTimeout time.Duration `json:"tlsHandshakeTimeout,omitempty"`
+	RequestTimeout      time.Duration `json:"requestTimeout,omitempty"`
+
+	// TokenSource provides the platform API token, it is chosen from the OAuth2 and token file
+	// settings when it is not set
+	TokenSource TokenSource
+
+	// OAuthTokenURL is the token endpoint of the OAuth2 client credentials exchange, falls back to
+	// the OAUTH_TOKEN_URL environment variable
+	OAuthTokenURL string `json:"oauthTokenUrl,omitempty"`
+
+	// OAuthClientID and OAuthClientSecret are the OAuth2 client credentials, they fall back to the
+	// OAUTH_CLIENT_ID and OAUTH_CLIENT_SECRET environment variables
+	OAuthClientID     string `json:"oauthClientId,omitempty"`
+	OAuthClientSecret string `json:"-"`
+
+	// OAuthScopes are the space separated scopes requested with the token, falls back to the
+	// OAUTH_SCOPES environment variable
+	OAuthScopes string `json:"oauthScopes,omitempty"`
+
+	// Approvers is a comma separated list of approvers, falls back to the APPROVERS environment variable
+	Approvers string `json:"approvers,omitempty"`
+
+	// Delegations hands the approvals of approvers to substitutes, one "<approver> -> <substitute>
+	// [from <yyyy-mm-dd>] [to <yyyy-mm-dd>]" per line. Falls back to the DELEGATIONS environment variable.
+	Delegations string `json:"delegations,omitempty"`
+
+	// DelegationsFile is a file with delegations in the same format, falls back to the
+	// DELEGATIONS_FILE environment variable
+	DelegationsFile string `json:"delegationsFile,omitempty"`
+
+	// DisallowedApprovers lists users who may not approve, comma or newline separated. Entries are user
+	// IDs, user names, email addresses or "authors-of: <commit range>" for the authors of the commits in
+	// the range of the git checkout. Falls back to the DISALLOWED_APPROVERS environment variable.
+	DisallowedApprovers string `json:"disallowedApprovers,omitempty"`
+
+	// DisallowedAuthors holds the authors of the authors-of commit ranges as resolved by the init
+	// handler, a JSON object mapping each range to its authors. The callback handler checks the
+	// approver against them instead of running git. Falls back to the DISALLOWED_AUTHORS environment
+	// variable.
+	DisallowedAuthors string `json:"disallowedAuthors,omitempty"`
+
+	// DistinctFrom holds the decisionRecord outputs of earlier approval jobs of the workflow, as a JSON
+	// list or one per line. Their approvers may not approve again. Falls back to the DISTINCT_FROM
+	// environment variable.
+	DistinctFrom string `json:"distinctFrom,omitempty"`
+
+	// ApprovalKey identifies what is approved, such as an image digest. Approvals are saved to the
+	// approval store under the key. Falls back to the APPROVAL_KEY environment variable.
+	ApprovalKey string `json:"approvalKey,omitempty"`
+
+	// ReuseWindow is how long an approval of the same approval key is reused instead of requesting a
+	// new one, such as 24h. Falls back to the REUSE_WINDOW environment variable.
+	ReuseWindow string `json:"reuseWindow,omitempty"`
+
+	// ApprovalStore is the file or the http(s) URL approvals are saved to and read from, falls back to
+	// the APPROVAL_STORE environment variable
+	ApprovalStore string `json:"approvalStore,omitempty"`
+
+	// ApprovalStoreToken is sent as bearer token to an HTTP approval store, falls back to the
+	// APPROVAL_STORE_TOKEN environment variable
+	ApprovalStoreToken string `json:"-"`
+
+	// Instructions for approvers in markdown format, falls back to the INSTRUCTIONS environment variable
+	Instructions string `json:"instructions,omitempty"`
+
+	// DisallowLaunchedByUser falls back to the DISALLOW_LAUNCHED_BY_USER environment variable
+	DisallowLaunchedByUser string `json:"disallowLaunchedByUser,omitempty"`
+
+	// NotifyAllEligibleUsers falls back to the NOTIFY_ALL_ELIGIBLE_USERS environment variable
+	NotifyAllEligibleUsers string `json:"notifyAllEligibleUsers,omitempty"`
+
+	// Inputs is the approvalInputs definition, falls back to the INPUTS environment variable
+	Inputs string `json:"inputs,omitempty"`
+
+	// RequireCommentOnReject falls back to the REQUIRE_COMMENT_ON_REJECT environment variable. When
+	// true, rejections without a comment are not accepted.
+	RequireCommentOnReject string `json:"requireCommentOnReject,omitempty"`
+
+	// MinCommentLength is the minimum number of characters of a rejection comment, falls back to the
+	// MIN_COMMENT_LENGTH environment variable
+	MinCommentLength string `json:"minCommentLength,omitempty"`
+
+	// RejectionReasons is a comma separated list of reason codes one of which every rejection has to
+	// give, falls back to the REJECTION_REASONS environment variable
+	RejectionReasons string `json:"rejectionReasons,omitempty"`
+
+	// Payload is the callback handler payload, falls back to the PAYLOAD environment variable
+	Payload string `json:"payload,omitempty"`
+
+	// CancellationReason falls back to the CANCELLATION_REASON environment variable
+	CancellationReason string `json:"cancellationReason,omitempty"`
+
+	// File is a workflow or job configuration YAML file checked by the validate handler
+	File string `json:"file,omitempty"`
+
+	// OutputsDir is the directory job outputs are written to, falls back to the CLOUDBEES_OUTPUTS environment variable
+	OutputsDir string `json:"outputsDir,omitempty"`
+
+	// InputOutputs falls back to the INPUT_OUTPUTS environment variable. When true, every approval input
+	// value is also written to its own input_<name> output.
+	InputOutputs string `json:"inputOutputs,omitempty"`
+
+	// DryRun logs platform API requests instead of sending them and writes the outputs and status to
+	// a temporary directory
+	DryRun bool `json:"dryRun,omitempty"`
+
+	// StatusFile is the file the job status is written to, falls back to the CLOUDBEES_STATUS environment variable
+	StatusFile string `json:"statusFile,omitempty"`
+
+	// ApprovalID identifies the approval request of the status, approve and reject handlers
+	ApprovalID string `json:"approvalId,omitempty"`
+
+	// Comments of the approve and reject handlers
+	Comments string `json:"comments,omitempty"`
+
+	// ReasonCode is the rejection reason code of the reject handler
+	ReasonCode string `json:"reasonCode,omitempty"`
+
+	// InputValues are name=value pairs of approval input values given to the approve and reject handlers
+	InputValues []string `json:"inputValues,omitempty"`
+
+	// Pending limits the list handler to approval requests waiting for a response
+	Pending bool `json:"pending,omitempty"`
+
+	// Format of the status and list handler output: table or json
+	Format string `json:"format,omitempty"`
+
+	// BackendType selects where approvals are requested: platform, tty or file.
+	// Falls back to the APPROVAL_BACKEND environment variable.
+	BackendType string `json:"backendType,omitempty"`
+
+	// BackendDir is the directory the file backend exchanges request and response files in,
+	// falls back to the APPROVAL_DIR environment variable
+	BackendDir string `json:"backendDir,omitempty"`
+
+	// PollInterval is the initial interval between approval status checks of the wait handler
+	PollInterval time.Duration `json:"pollInterval,omitempty"`
+
+	// Timeout is how long the wait handler waits for a response before the request times out
+	Timeout time.Duration `json:"timeout,omitempty"`
+
+	// Debug enables debug logging in addition to the DEBUG environment variable
+	Debug bool `json:"debug,omitempty"`
+
+	// OutputMode selects how instructions and input values are written to the log: html, ansi or plain.
+	// It is chosen automatically based on whether stdout is a terminal when not set.
+	OutputMode string `json:"outputMode,omitempty"`
+
+	// clock returns the current time, time.Now is used when it is not set
+	clock func() time.Time
+
+	// git runs git with the arguments and returns its output, the git command is run when it is not set
+	git func(args ...string) ([]byte, error)
+
+	// resolvedAuthors are the authors of the authors-of commit ranges git was run for
+	resolvedAuthors map[string][]string
+
+	// statusDetails and statusError are written to the status file with the next status
+	statusDetails statusDetails
+	statusError   error
+
+	// dryRunRequests are the platform API requests logged instead of sent
+	dryRunRequests []string
+
+	// dryRunFiles are the file backend files logged instead of written
+	dryRunFiles []string
+
+	// inputSchema is the approvalInputs definition the response values were checked against
+	inputSchema []ApprovalInput
 }
 
 type CreateManualApprovalResponse struct {
+	ID        string      `json:"id"`
 	Approvers []Approvers `json:"approvers"`
 }
 
@@ -32,3 +241,34 @@ type Approvers struct {
 	UserId   string `json:"userId"`
 	Email    string `json:"email"`
 }
+
+const (
+	ApprovalStatusPending  = "PENDING_APPROVAL"
+	ApprovalStatusApproved = "APPROVED"
+	ApprovalStatusRejected = "REJECTED"
+	ApprovalStatusTimedOut = "TIMED_OUT"
+	ApprovalStatusAborted  = "ABORTED"
+)
+
+// ApprovalRequest is a manual approval request as returned by the platform API
+type ApprovalRequest struct {
+	ID             string      `json:"id"`
+	Status         string      `json:"status"`
+	Approvers      []Approvers `json:"approvers"`
+	Instructions   string      `json:"instructions,omitempty"`
+	ApprovalInputs string      `json:"approvalInputs,omitempty"`
+	CreatedOn      time.Time   `json:"createdOn"`
+	ExpiresOn      time.Time   `json:"expiresOn"`
+
+	// Response of the approver once the request is approved or rejected
+	UserID      string        `json:"userId,omitempty"`
+	UserName    string        `json:"userName,omitempty"`
+	RespondedOn string        `json:"respondedOn,omitempty"`
+	Comments    string        `json:"comments,omitempty"`
+	ReasonCode  string        `json:"reasonCode,omitempty"`
+	Inputs      []interface{} `json:"inputs,omitempty"`
+}
+
+type ListManualApprovalResponse struct {
+	Approvals []ApprovalRequest `json:"approvals"`
+}
@@ -0,0 +1,365 @@
+package manual_approval
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/text"
+	"gopkg.in/yaml.v3"
+)
+
+// Problem is a configuration mistake found by the validate handler
+type Problem struct {
+	Source  string
+	Line    int
+	Field   string
+	Message string
+}
+
+func (p Problem) String() string {
+	location := p.Source
+	if p.Line > 0 {
+		location = fmt.Sprintf("%s:%d", p.Source, p.Line)
+	}
+	return fmt.Sprintf("%s: %s: %s", location, p.Field, p.Message)
+}
+
+// jobConfig is the configuration of a single manual approval job, with the
+// YAML node of every field to report line numbers
+type jobConfig struct {
+	source string
+	prefix string
+	fields map[string]*yaml.Node
+}
+
+// validate checks the approval job configuration offline, without calling the platform API
+func (k *Config) validate() error {
+	debugf("Inside validate handler\n")
+
+	var jobs []jobConfig
+	if k.File != "" {
+		var err error
+		jobs, err = readJobConfigs(k.File)
+		if err != nil {
+			return err
+		}
+	} else {
+		jobs = []jobConfig{k.flagJobConfig()}
+	}
+
+	var problems []Problem
+	for _, job := range jobs {
+		problems = append(problems, job.validate()...)
+		problems = append(problems, k.validateGit(job)...)
+	}
+
+	if len(problems) == 0 {
+		k.Output.Printf("Configuration is valid\n")
+		return nil
+	}
+	for _, problem := range problems {
+		k.Output.Printf("%s\n", problem)
+	}
+	return validationErrorf("configuration is invalid: %d problem(s) found", len(problems))
+}
+
+// validateGit reports a missing git command when the job resolves authors-of commit ranges, which
+// the init handler needs git and the checkout for
+func (k *Config) validateGit(job jobConfig) []Problem {
+	node, ok := job.fields["disallowedApprovers"]
+	if !ok || isExpression(node.Value) {
+		return nil
+	}
+	if _, ranges, err := parseDisallowedApprovers(node.Value); err != nil || len(ranges) == 0 {
+		return nil
+	}
+	if _, err := k.gitOutput("version"); err != nil {
+		return []Problem{{Source: job.source, Line: node.Line, Field: job.prefix + "disallowedApprovers", Message: err.Error()}}
+	}
+	return nil
+}
+
+// flagJobConfig builds the job configuration from flags and environment variables
+func (k *Config) flagJobConfig() jobConfig {
+	job := jobConfig{source: "flags", fields: map[string]*yaml.Node{}}
+	values := map[string]string{
+		"approvers":              valueOrEnv(k.Approvers, "APPROVERS"),
+		"instructions":           valueOrEnv(k.Instructions, "INSTRUCTIONS"),
+		"disallowLaunchByUser":   valueOrEnv(k.DisallowLaunchedByUser, "DISALLOW_LAUNCHED_BY_USER"),
+		"notifyAllEligibleUsers": valueOrEnv(k.NotifyAllEligibleUsers, "NOTIFY_ALL_ELIGIBLE_USERS"),
+		"approvalInputs":         valueOrEnv(k.Inputs, "INPUTS"),
+		"requireCommentOnReject": valueOrEnv(k.RequireCommentOnReject, "REQUIRE_COMMENT_ON_REJECT"),
+		"minCommentLength":       valueOrEnv(k.MinCommentLength, "MIN_COMMENT_LENGTH"),
+		"rejectionReasons":       valueOrEnv(k.RejectionReasons, "REJECTION_REASONS"),
+		"disallowedApprovers":    valueOrEnv(k.DisallowedApprovers, "DISALLOWED_APPROVERS"),
+		"distinctFrom":           valueOrEnv(k.DistinctFrom, "DISTINCT_FROM"),
+		"approvalKey":            valueOrEnv(k.ApprovalKey, "APPROVAL_KEY"),
+		"reuseWindow":            valueOrEnv(k.ReuseWindow, "REUSE_WINDOW"),
+		"approvalStore":          valueOrEnv(k.ApprovalStore, "APPROVAL_STORE"),
+		"delegations":            valueOrEnv(k.Delegations, "DELEGATIONS"),
+		"delegationsFile":        valueOrEnv(k.DelegationsFile, "DELEGATIONS_FILE"),
+	}
+	for name, value := range values {
+		if value != "" {
+			job.fields[name] = &yaml.Node{Kind: yaml.ScalarNode, Value: value}
+		}
+	}
+	return job
+}
+
+// readJobConfigs reads the manual approval job configuration from a YAML file. The file is either a
+// workflow, in which case every job delegating to the manual approval custom job is checked, or the
+// `with` mapping of a single job.
+func readJobConfigs(file string) ([]jobConfig, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", file, err)
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", file, err)
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse %s: expected a mapping", file)
+	}
+	root := doc.Content[0]
+
+	jobsNode := mappingValue(root, "jobs")
+	if jobsNode == nil {
+		return []jobConfig{{source: file, fields: mappingFields(root)}}, nil
+	}
+
+	var jobs []jobConfig
+	for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+		name, job := jobsNode.Content[i], jobsNode.Content[i+1]
+		delegates := mappingValue(job, "delegates")
+		if delegates == nil || !strings.Contains(delegates.Value, "manual-approval") {
+			continue
+		}
+		jobs = append(jobs, jobConfig{
+			source: file,
+			prefix: fmt.Sprintf("jobs.%s.with.", name.Value),
+			fields: mappingFields(mappingValue(job, "with")),
+		})
+	}
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("no manual approval jobs found in %s", file)
+	}
+	return jobs, nil
+}
+
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func mappingFields(node *yaml.Node) map[string]*yaml.Node {
+	fields := map[string]*yaml.Node{}
+	if node == nil || node.Kind != yaml.MappingNode {
+		return fields
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		fields[node.Content[i].Value] = node.Content[i+1]
+	}
+	return fields
+}
+
+func (job jobConfig) validate() []Problem {
+	var problems []Problem
+	report := func(field string, line int, format string, a ...any) {
+		problems = append(problems, Problem{Source: job.source, Line: line, Field: job.prefix + field, Message: fmt.Sprintf(format, a...)})
+	}
+
+	for _, field := range []string{"disallowLaunchByUser", "notifyAllEligibleUsers", "requireCommentOnReject"} {
+		if node, ok := job.fields[field]; ok && !isExpression(node.Value) {
+			if _, err := strconv.ParseBool(node.Value); err != nil {
+				report(field, node.Line, "%s", err)
+			}
+		}
+	}
+
+	if node, ok := job.fields["minCommentLength"]; ok && !isExpression(node.Value) {
+		if _, err := parseMinCommentLength(node.Value); err != nil {
+			report("minCommentLength", node.Line, "%s", err)
+		}
+	}
+
+	if node, ok := job.fields["rejectionReasons"]; ok && !isExpression(node.Value) {
+		if _, err := parseRejectionReasons(node.Value); err != nil {
+			report("rejectionReasons", node.Line, "%s", err)
+		}
+	}
+
+	if node, ok := job.fields["approvers"]; ok && !isExpression(node.Value) {
+		for _, message := range validateApprovers(node.Value) {
+			report("approvers", node.Line, "%s", message)
+		}
+	}
+
+	if node, ok := job.fields["disallowedApprovers"]; ok && !isExpression(node.Value) {
+		if _, _, err := parseDisallowedApprovers(node.Value); err != nil {
+			report("disallowedApprovers", node.Line, "%s", err)
+		}
+	}
+
+	if node, ok := job.fields["distinctFrom"]; ok && !isExpression(node.Value) {
+		if _, err := parseDecisionRecords(node.Value); err != nil {
+			report("distinctFrom", node.Line, "%s", err)
+		}
+	}
+
+	if node, ok := job.fields["reuseWindow"]; ok && !isExpression(node.Value) {
+		if _, err := parseReuseWindow(node.Value); err != nil {
+			report("reuseWindow", node.Line, "%s", err)
+		}
+		if _, ok := job.fields["approvalKey"]; !ok {
+			report("reuseWindow", node.Line, "requires approvalKey")
+		}
+	}
+
+	if node, ok := job.fields["approvalKey"]; ok {
+		if _, ok := job.fields["approvalStore"]; !ok {
+			report("approvalKey", node.Line, "requires approvalStore")
+		}
+	}
+
+	if node, ok := job.fields["approvalStore"]; ok && !isExpression(node.Value) {
+		if _, err := isHTTPStore(node.Value); err != nil {
+			report("approvalStore", node.Line, "%s", err)
+		}
+	}
+
+	if node, ok := job.fields["delegations"]; ok && !isExpression(node.Value) {
+		_, err := parseDelegations(node.Value)
+		var delegationErr *DelegationError
+		if errors.As(err, &delegationErr) {
+			report("delegations", contentLine(node, delegationErr.Line), "%s", delegationErr.Message)
+		}
+	}
+
+	if node, ok := job.fields["delegationsFile"]; ok && !isExpression(node.Value) {
+		content, err := os.ReadFile(node.Value)
+		if err == nil {
+			_, err = parseDelegations(string(content))
+		}
+		if err != nil {
+			report("delegationsFile", node.Line, "%s", err)
+		}
+	}
+
+	if node, ok := job.fields["instructions"]; ok && !isExpression(node.Value) {
+		for _, problem := range validateMarkdown(node.Value) {
+			report("instructions", contentLine(node, problem.Line), "%s", problem.Message)
+		}
+	}
+
+	if node, ok := job.fields["approvalInputs"]; ok && !isExpression(node.Value) {
+		_, err := parseApprovalInputs(node.Value)
+		var inputErrs InputErrors
+		if errors.As(err, &inputErrs) {
+			for _, inputErr := range inputErrs {
+				message := inputErr.Message
+				if inputErr.Input != "" {
+					message = fmt.Sprintf("input '%s': %s", inputErr.Input, inputErr.Message)
+				}
+				report("approvalInputs", contentLine(node, inputErr.Line), "%s", message)
+			}
+		}
+	}
+
+	return problems
+}
+
+// contentLine maps a line within a scalar value to the line in the YAML file
+func contentLine(node *yaml.Node, line int) int {
+	if node.Line == 0 {
+		return line
+	}
+	if line == 0 {
+		return node.Line
+	}
+	if node.Style == yaml.LiteralStyle || node.Style == yaml.FoldedStyle {
+		// Block scalars start on the line after the indicator
+		return node.Line + line
+	}
+	return node.Line
+}
+
+// isExpression reports whether the value is evaluated by the platform at runtime
+func isExpression(value string) bool {
+	return strings.Contains(value, "${{")
+}
+
+// validateApprovers checks the format of the comma separated list of user IDs and email addresses
+func validateApprovers(approvers string) []string {
+	var messages []string
+	var seen []string
+	for i, approver := range strings.Split(approvers, ",") {
+		switch {
+		case approver == "":
+			messages = append(messages, fmt.Sprintf("approver %d is empty", i+1))
+		case strings.TrimSpace(approver) != approver || strings.ContainsAny(approver, " \t\n"):
+			messages = append(messages, fmt.Sprintf("approver '%s' must not contain whitespace", approver))
+		case strings.Contains(approver, "@") && !isEmail(approver):
+			messages = append(messages, fmt.Sprintf("approver '%s' is not a valid email address", approver))
+		case slices.Contains(seen, approver):
+			messages = append(messages, fmt.Sprintf("approver '%s' is listed more than once", approver))
+		}
+		seen = append(seen, approver)
+	}
+	return messages
+}
+
+func isEmail(value string) bool {
+	address, err := mail.ParseAddress(value)
+	return err == nil && address.Address == value
+}
+
+// validateMarkdown reports markdown that is not rendered the way the author expects
+func validateMarkdown(value string) []Problem {
+	source := []byte(value)
+	md := goldmark.New()
+	var problems []Problem
+	if err := md.Convert(source, &strings.Builder{}); err != nil {
+		return []Problem{{Message: fmt.Sprintf("failed to render markdown: %s", err)}}
+	}
+
+	doc := md.Parser().Parse(text.NewReader(source))
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n := n.(type) {
+		case *ast.HTMLBlock:
+			if n.Lines().Len() > 0 {
+				problems = append(problems, Problem{Line: lineOf(source, n.Lines().At(0).Start), Message: "raw HTML is not rendered, use markdown instead"})
+			}
+		case *ast.RawHTML:
+			if n.Segments.Len() > 0 {
+				problems = append(problems, Problem{Line: lineOf(source, n.Segments.At(0).Start), Message: "raw HTML is not rendered, use markdown instead"})
+			}
+		}
+		return ast.WalkContinue, nil
+	})
+	return problems
+}
+
+// lineOf returns the 1-based line number of the offset in the source
+func lineOf(source []byte, offset int) int {
+	return strings.Count(string(source[:offset]), "\n") + 1
+}
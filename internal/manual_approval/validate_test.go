@@ -0,0 +1,141 @@
+package manual_approval
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_validate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		env    map[string]string
+		output []string
+		err    string
+	}{
+		{
+			name:   "valid job file",
+			config: Config{File: "testdata/validate/valid-job.yaml"},
+			output: []string{"Configuration is valid\n"},
+		},
+		{
+			name:   "invalid workflow file",
+			config: Config{File: "testdata/validate/invalid-workflow.yaml"},
+			output: []string{
+				"testdata/validate/invalid-workflow.yaml:13: jobs.approve.with.disallowLaunchByUser: strconv.ParseBool: parsing \"nope\": invalid syntax\n",
+				"testdata/validate/invalid-workflow.yaml:12: jobs.approve.with.approvers: approver 2 is empty\n",
+				"testdata/validate/invalid-workflow.yaml:12: jobs.approve.with.approvers: approver 'bad@' is not a valid email address\n",
+				"testdata/validate/invalid-workflow.yaml:12: jobs.approve.with.approvers: approver 'a@b.com' is listed more than once\n",
+				"testdata/validate/invalid-workflow.yaml:16: jobs.approve.with.instructions: raw HTML is not rendered, use markdown instead\n",
+				"testdata/validate/invalid-workflow.yaml:18: jobs.approve.with.approvalInputs: input 'in1': unsupported type 'strin', expected one of: string, number, boolean, choice, multichoice, datetime, url, integer, text\n",
+				"testdata/validate/invalid-workflow.yaml:22: jobs.approve.with.approvalInputs: input 'in2': invalid default: 'abc' is not a number\n",
+				"testdata/validate/invalid-workflow.yaml:25: jobs.approve.with.approvalInputs: input 'in3': unknown attribute 'reqired', expected one of: type, description, required, default, options, pattern, minLength, maxLength, minimum, maximum, errorMessage, when\n",
+				"testdata/validate/invalid-workflow.yaml:23: jobs.approve.with.approvalInputs: input 'in3': options are required for choice inputs\n",
+			},
+			err: "configuration is invalid: 9 problem(s) found",
+		},
+		{
+			name:   "missing file",
+			config: Config{File: "testdata/validate/missing.yaml"},
+			err:    "failed to read testdata/validate/missing.yaml: open testdata/validate/missing.yaml: no such file or directory",
+		},
+		{
+			name:   "flags",
+			config: Config{Approvers: "123, user@mail.com", Inputs: "in1:\n  type: number\n  default: x"},
+			env:    map[string]string{"NOTIFY_ALL_ELIGIBLE_USERS": "yes please"},
+			output: []string{
+				"flags: notifyAllEligibleUsers: strconv.ParseBool: parsing \"yes please\": invalid syntax\n",
+				"flags: approvers: approver ' user@mail.com' must not contain whitespace\n",
+				"flags:3: approvalInputs: input 'in1': invalid default: 'x' is not a number\n",
+			},
+			err: "configuration is invalid: 3 problem(s) found",
+		},
+		{
+			name:   "rejection policy flags",
+			config: Config{RequireCommentOnReject: "sometimes", MinCommentLength: "ten", RejectionReasons: "security,,timing"},
+			output: []string{
+				"flags: requireCommentOnReject: strconv.ParseBool: parsing \"sometimes\": invalid syntax\n",
+				"flags: minCommentLength: strconv.Atoi: parsing \"ten\": invalid syntax\n",
+				"flags: rejectionReasons: empty reason code\n",
+			},
+			err: "configuration is invalid: 3 problem(s) found",
+		},
+		{
+			name:   "approval reuse flags",
+			config: Config{ApprovalKey: "sha256:4f53", ReuseWindow: "1 day"},
+			output: []string{
+				"flags: reuseWindow: time: unknown unit \" day\" in duration \"1 day\"\n",
+				"flags: approvalKey: requires approvalStore\n",
+			},
+			err: "configuration is invalid: 2 problem(s) found",
+		},
+		{
+			name:   "distinct from flag",
+			config: Config{DistinctFrom: `{"schemaVersion":1,"decision":"APPROVED","approver":"jane"}` + "\n" + `{"schemaVersion":1,"decision":"APPROVED"}`},
+			output: []string{
+				"flags: distinctFrom: record 2: approver is missing\n",
+			},
+			err: "configuration is invalid: 1 problem(s) found",
+		},
+		{
+			name:   "delegations and disallowed approvers flags",
+			config: Config{DisallowedApprovers: "123, authors-of:", Delegations: "alice -> bob\nbob -> bob", DelegationsFile: "testdata/validate/missing-delegations.txt"},
+			output: []string{
+				"flags: disallowedApprovers: invalid commit range '' in 'authors-of:'\n",
+				"flags:2: delegations: 'bob' cannot be delegated to itself\n",
+				"flags: delegationsFile: open testdata/validate/missing-delegations.txt: no such file or directory\n",
+			},
+			err: "configuration is invalid: 3 problem(s) found",
+		},
+		{
+			name: "authors-of without git",
+			config: Config{
+				DisallowedApprovers: "authors-of: origin/main..HEAD",
+				git: func(args ...string) ([]byte, error) {
+					return nil, &exec.Error{Name: "git", Err: exec.ErrNotFound}
+				},
+			},
+			output: []string{
+				"flags: disallowedApprovers: git is not available, authors-of needs git and a checkout of the repository\n",
+			},
+			err: "configuration is invalid: 1 problem(s) found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			for k, v := range tt.env {
+				os.Setenv(k, v)
+				defer func(k string) {
+					os.Unsetenv(k)
+				}(k)
+			}
+
+			var testOutput []string
+
+			// Run
+			c := tt.config
+			c.Output = &MockStdOut{
+				MockPrintf: func(format string, a ...any) {
+					testOutput = append(testOutput, fmt.Sprintf(format, a...))
+				},
+			}
+			err := c.validate()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+
+			require.True(t, slices.Equal(tt.output, testOutput), "%q", testOutput)
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package manual_approval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// jsonNumber matches number literals that are valid JSON as they are
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// decodeJSON decodes data into v keeping numbers as json.Number, so large integers and decimals are
+// passed on exactly as the approver entered them
+func decodeJSON(data []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return fmt.Errorf("invalid data after top-level value")
+	}
+	return nil
+}
+
+// valueToString encodes an input value as a string: strings as is, nil as the empty string,
+// booleans as true or false, numbers without exponent or trailing zeros, json.Number included, and
+// lists and objects as JSON with sorted keys
+func valueToString(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case json.Number:
+		return plainNumber(v), nil
+	case []interface{}, map[string]interface{}:
+		if err := checkValue(v); err != nil {
+			return "", err
+		}
+		out, err := json.Marshal(v)
+		if err != nil {
+			return "", err
+		}
+		return string(out), nil
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return "", fmt.Errorf("unsupported input value %v", f)
+		}
+		return strconv.FormatFloat(f, 'f', -1, rv.Type().Bits()), nil
+	}
+	return "", fmt.Errorf("unsupported input value type %T", value)
+}
+
+// maxPlainExponent is the largest exponent plainNumber expands, larger ones are kept as given
+const maxPlainExponent = 1000
+
+// plainNumber writes a JSON number exactly, without exponent or trailing zeros
+func plainNumber(number json.Number) string {
+	if i := strings.IndexAny(number.String(), "eE"); i >= 0 {
+		exponent, err := strconv.Atoi(number.String()[i+1:])
+		if err != nil || exponent > maxPlainExponent || exponent < -maxPlainExponent {
+			return number.String()
+		}
+	}
+	r, ok := new(big.Rat).SetString(number.String())
+	if !ok {
+		return number.String()
+	}
+	if r.IsInt() {
+		return r.Num().String()
+	}
+	// A decimal literal has a denominator dividing a power of ten, the smallest such power is the
+	// number of decimals
+	ten := big.NewInt(10)
+	power := new(big.Int).Set(ten)
+	decimals := 1
+	for new(big.Int).Mod(power, r.Denom()).Sign() != 0 {
+		power.Mul(power, ten)
+		decimals++
+	}
+	return r.FloatString(decimals)
+}
+
+// checkValue fails for values that valueToString cannot encode, including values nested in lists
+// and objects
+func checkValue(value interface{}) error {
+	switch v := value.(type) {
+	case []interface{}:
+		for _, item := range v {
+			if err := checkValue(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case map[string]interface{}:
+		for _, item := range v {
+			if err := checkValue(item); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err := valueToString(value)
+	return err
+}
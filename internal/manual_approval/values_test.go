@@ -0,0 +1,163 @@
+package manual_approval
+
+import (
+	"encoding/json"
+	"math"
+	"math/rand"
+	"reflect"
+	"strconv"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_valueToString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		want  string
+		err   string
+	}{
+		{name: "nil", value: nil, want: ""},
+		{name: "string", value: "a b", want: "a b"},
+		{name: "boolean", value: true, want: "true"},
+		{name: "int", value: 64, want: "64"},
+		{name: "negative int64", value: int64(-9007199254740993), want: "-9007199254740993"},
+		{name: "uint64", value: uint64(18446744073709551615), want: "18446744073709551615"},
+		{name: "float", value: 9.5, want: "9.5"},
+		{name: "large float", value: 1e21, want: "1000000000000000000000"},
+		{name: "json number", value: json.Number("12345678901234567890"), want: "12345678901234567890"},
+		{name: "json number with exponent", value: json.Number("1e3"), want: "1000"},
+		{name: "json number with negative exponent", value: json.Number("-1.25E-2"), want: "-0.0125"},
+		{name: "json number with trailing zeros", value: json.Number("2.500"), want: "2.5"},
+		{name: "json number with a huge exponent", value: json.Number("1e-100000"), want: "1e-100000"},
+		{name: "list", value: []interface{}{"a", json.Number("1"), nil, false}, want: `["a",1,null,false]`},
+		{name: "object", value: map[string]interface{}{"b": []interface{}{}, "a": map[string]interface{}{"z": 1.5}}, want: `{"a":{"z":1.5},"b":[]}`},
+		{name: "NaN", value: math.NaN(), err: "unsupported input value NaN"},
+		{name: "infinity", value: math.Inf(1), err: "unsupported input value +Inf"},
+		{name: "unknown type", value: struct{}{}, err: "unsupported input value type struct {}"},
+		{name: "unknown type in a list", value: []interface{}{"a", []string{"b"}}, err: "unsupported input value type []string"},
+		{name: "unknown type in an object", value: map[string]interface{}{"a": make(chan int)}, err: "unsupported input value type chan int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := valueToString(tt.value)
+			if tt.err != "" {
+				require.EqualError(t, err, tt.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_decodeJSON(t *testing.T) {
+	// Run
+	approval := &ApprovalRequest{}
+	err := decodeJSON([]byte(`{"id":"a-1","inputs":[{"name":"build","value":12345678901234567890},{"name":"ratio","value":0.1}]}`), approval)
+
+	// Verify
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{
+		map[string]interface{}{"name": "build", "value": json.Number("12345678901234567890")},
+		map[string]interface{}{"name": "ratio", "value": json.Number("0.1")},
+	}, approval.Inputs)
+
+	require.EqualError(t, decodeJSON([]byte(`{"id":"a-1"} {}`), approval), "invalid data after top-level value")
+}
+
+func Test_formatInputsForPostInvalidValue(t *testing.T) {
+	_, _, err := formatInputsForPost(map[string]interface{}{
+		"inputs": []interface{}{map[string]interface{}{"name": "ratio", "value": math.NaN()}},
+	})
+	require.EqualError(t, err, "invalid value for input 'ratio': unsupported input value NaN")
+	require.Equal(t, ExitValidation, ExitCode(err))
+}
+
+// jsonValue is a random JSON value as the callback payload carries it
+type jsonValue struct {
+	value interface{}
+}
+
+func (jsonValue) Generate(r *rand.Rand, _ int) reflect.Value {
+	return reflect.ValueOf(jsonValue{randomJSONValue(r, 3)})
+}
+
+func randomJSONValue(r *rand.Rand, depth int) interface{} {
+	kinds := 7
+	if depth == 0 {
+		kinds = 5
+	}
+	switch r.Intn(kinds) {
+	case 0:
+		return nil
+	case 1:
+		return r.Intn(2) == 1
+	case 2:
+		const alphabet = "ab Z09-_.,:\"\\\n\t<>&äö€😀"
+		runes := []rune(alphabet)
+		s := make([]rune, r.Intn(12))
+		for i := range s {
+			s[i] = runes[r.Intn(len(runes))]
+		}
+		return string(s)
+	case 3:
+		if r.Intn(2) == 1 {
+			return json.Number(strconv.FormatUint(r.Uint64(), 10))
+		}
+		return json.Number(strconv.FormatInt(-r.Int63(), 10))
+	case 4:
+		return json.Number(strconv.FormatFloat(r.NormFloat64()*math.Pow10(r.Intn(40)-20), 'g', -1, 64))
+	case 5:
+		list := make([]interface{}, r.Intn(4))
+		for i := range list {
+			list[i] = randomJSONValue(r, depth-1)
+		}
+		return list
+	default:
+		object := map[string]interface{}{}
+		for i := r.Intn(4); i > 0; i-- {
+			object["k"+strconv.Itoa(r.Intn(10))] = randomJSONValue(r, depth-1)
+		}
+		return object
+	}
+}
+
+func Test_approvalInputValuesRoundTrip(t *testing.T) {
+	property := func(v jsonValue) bool {
+		payload, err := json.Marshal(map[string]interface{}{
+			"inputs": []interface{}{map[string]interface{}{"name": "value", "value": v.value}},
+		})
+		if err != nil {
+			return false
+		}
+		parsedPayload := map[string]interface{}{}
+		if err := decodeJSON(payload, &parsedPayload); err != nil {
+			return false
+		}
+
+		inputs, outputsMap, err := formatInputsForPost(parsedPayload)
+		if err != nil {
+			return false
+		}
+		outputBytes, err := json.Marshal(outputsMap)
+		if err != nil {
+			return false
+		}
+		var output map[string]interface{}
+		if err := decodeJSON(outputBytes, &output); err != nil {
+			return false
+		}
+
+		// The value sent to the platform is the string form of the original value
+		posted := inputs[0].(map[string]interface{})["value"]
+		expected, err := valueToString(v.value)
+		if err != nil || posted != expected {
+			return false
+		}
+		return reflect.DeepEqual(v.value, output["value"])
+	}
+	require.NoError(t, quick.Check(property, &quick.Config{MaxCount: 2000}))
+}
@@ -0,0 +1,147 @@
+package manual_approval
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultPollInterval = 5 * time.Second
+	maxPollInterval     = time.Minute
+	defaultWaitTimeout  = 4320 * time.Minute
+)
+
+// wait creates the approval request the way the init handler does and polls its status until it is
+// approved, rejected or the timeout passes, writing the same outputs and status as the callback handler
+func (k *Config) wait() error {
+	debugf("Inside wait handler\n")
+
+	if _, err := k.backend(); err != nil {
+		return err
+	}
+	ctx := k.ctx()
+
+	if reused, err := k.reuseApproval(); reused || err != nil {
+		return err
+	}
+
+	created, err := k.requestApproval()
+	if err != nil {
+		return err
+	}
+	if created.ID == "" {
+		return fmt.Errorf("approval request ID missing in the API response")
+	}
+
+	timeout := k.Timeout
+	if timeout <= 0 {
+		timeout = defaultWaitTimeout
+	}
+	interval := k.PollInterval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	deadline := k.now().Add(timeout)
+
+	for {
+		approval, err := k.getApproval(created.ID)
+		if err != nil && ctx.Err() != nil {
+			return k.abortWait(created.ID)
+		}
+		if err != nil {
+			ferr := k.writeErrorStatus(fmt.Sprintf("Failed to get workflow manual approval status: '%s'", errorMessage(err)), err)
+			if ferr != nil {
+				return ferr
+			}
+			return err
+		}
+		debugf("Approval status: '%s'\n", approval.Status)
+
+		switch approval.Status {
+		case ApprovalStatusApproved, ApprovalStatusRejected:
+			return k.completeWait(approval)
+		case ApprovalStatusTimedOut:
+			return k.stopWait(created.ID, ExitTimedOut, "Workflow approval response was not received within allotted time.")
+		case ApprovalStatusAborted:
+			return k.stopWait(created.ID, ExitCancelled, "Workflow approval request was aborted")
+		}
+
+		left := deadline.Sub(k.now())
+		if left <= 0 {
+			if err := k.cancelApproval("TIMED_OUT", created.ID); err != nil {
+				return err
+			}
+			return k.stopWait(created.ID, ExitTimedOut, "Workflow approval response was not received within allotted time.")
+		}
+
+		select {
+		case <-ctx.Done():
+			return k.abortWait(created.ID)
+		case <-time.After(min(interval, left)):
+		}
+		interval = min(interval*2, maxPollInterval)
+	}
+}
+
+// completeWait processes the approver response like the callback handler does
+func (k *Config) completeWait(approval *ApprovalRequest) error {
+	if k.statusDetails.requestedOn.IsZero() && !approval.CreatedOn.IsZero() {
+		k.statusDetails.requestedOn = approval.CreatedOn
+	}
+
+	inputs, err := k.checkInputValues(approval.Inputs, approval.Status == ApprovalStatusApproved)
+	if err != nil {
+		ferr := k.writeErrorStatus(fmt.Sprintf("Invalid approval input values: '%s'", err), err)
+		if ferr != nil {
+			return ferr
+		}
+		return err
+	}
+
+	payload := map[string]interface{}{
+		"inputs": inputs,
+	}
+	modifiedInputsParamForPost, outputsMap, err := formatInputsForPost(payload)
+	if err != nil {
+		return err
+	}
+
+	approvalStatus := "UPDATE_MANUAL_APPROVAL_STATUS_REJECTED"
+	if approval.Status == ApprovalStatusApproved {
+		approvalStatus = "UPDATE_MANUAL_APPROVAL_STATUS_APPROVED"
+	}
+	if err := k.checkRejectionPolicy(approvalStatus, approval.Comments, approval.ReasonCode); err != nil {
+		return err
+	}
+	if err := k.checkApproverAllowed(approvalStatus, approval.UserName, approval.UserID); err != nil {
+		return err
+	}
+	k.statusDetails.approverID = approval.UserID
+	err = k.completeApproval(approvalStatus, approval.UserName, approval.RespondedOn, approval.Comments, approval.ReasonCode, modifiedInputsParamForPost, outputsMap)
+	if err != nil {
+		return err
+	}
+
+	if approval.Status == ApprovalStatusRejected {
+		return &ExitError{Code: ExitRejected, Err: fmt.Errorf("approval request %s was rejected by %s", approval.ID, approval.UserName)}
+	}
+	return nil
+}
+
+// abortWait cancels the approval request when the run is cancelled
+func (k *Config) abortWait(id string) error {
+	if err := k.cancelApproval("CANCELLED", id); err != nil {
+		return err
+	}
+	return k.stopWait(id, ExitCancelled, "Workflow approval request was aborted")
+}
+
+// stopWait ends waiting for an approval request that will not be answered anymore
+func (k *Config) stopWait(id string, code int, message string) error {
+	err := &ExitError{Code: code, Err: fmt.Errorf("approval request %s: %s", id, message)}
+	ferr := k.writeErrorStatus(message, err)
+	if ferr != nil {
+		return ferr
+	}
+	return err
+}
@@ -0,0 +1,161 @@
+package manual_approval
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_wait(t *testing.T) {
+	tests := []struct {
+		name              string
+		statuses          []string
+		timeout           time.Duration
+		cancel            bool
+		statusPost        string
+		statusInFile      string
+		inputValsInOutput string
+		output            string
+		exitCode          int
+		err               string
+	}{
+		{
+			name: "approved",
+			statuses: []string{
+				`{"id":"a-1","status":"PENDING_APPROVAL"}`,
+				`{"id":"a-1","status":"APPROVED","userName":"testUserName","respondedOn":"2009-11-10T23:00:00Z","comments":"lgtm","inputs":[{"name":"replicas","value":3,"is_default":false}]}`,
+			},
+			statusInFile:      "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"APPROVED\"}",
+			inputValsInOutput: "{\"replicas\":3}",
+			output:            "Waiting for approval from one of the following: testUserName\nApproved by testUserName on 2009-11-10T23:00:00Z with comments:\nlgtm\n\nInput Parameters:\n------------------\n replicas: 3 \n",
+			exitCode:          ExitOK,
+		},
+		{
+			name: "rejected",
+			statuses: []string{
+				`{"id":"a-1","status":"REJECTED","userName":"testUserName","respondedOn":"2009-11-10T23:00:00Z","comments":"not now"}`,
+			},
+			statusInFile: "{\"message\":\"Successfully changed workflow manual approval status\",\"status\":\"REJECTED\"}",
+			output:       "Waiting for approval from one of the following: testUserName\nRejected by testUserName on 2009-11-10T23:00:00Z with comments:\nnot now\n",
+			exitCode:     ExitRejected,
+			err:          "approval request a-1 was rejected by testUserName",
+		},
+		{
+			name:         "timed out",
+			statuses:     []string{`{"id":"a-1","status":"PENDING_APPROVAL"}`},
+			timeout:      20 * time.Millisecond,
+			statusPost:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_TIMED_OUT"}`,
+			statusInFile: "{\"message\":\"Workflow approval response was not received within allotted time.\",\"status\":\"FAILED\"}",
+			output:       "Waiting for approval from one of the following: testUserName\nWorkflow timed out\nWorkflow approval response was not received within allotted time.\n",
+			exitCode:     ExitTimedOut,
+			err:          "approval request a-1: Workflow approval response was not received within allotted time.",
+		},
+		{
+			name:         "timed out by the platform",
+			statuses:     []string{`{"id":"a-1","status":"TIMED_OUT"}`},
+			statusInFile: "{\"message\":\"Workflow approval response was not received within allotted time.\",\"status\":\"FAILED\"}",
+			output:       "Waiting for approval from one of the following: testUserName\n",
+			exitCode:     ExitTimedOut,
+			err:          "approval request a-1: Workflow approval response was not received within allotted time.",
+		},
+		{
+			name:         "cancelled",
+			statuses:     []string{`{"id":"a-1","status":"PENDING_APPROVAL"}`},
+			cancel:       true,
+			statusPost:   `{"id":"a-1","status":"UPDATE_MANUAL_APPROVAL_STATUS_ABORTED"}`,
+			statusInFile: "{\"message\":\"Workflow approval request was aborted\",\"status\":\"FAILED\"}",
+			output:       "Waiting for approval from one of the following: testUserName\nWorkflow aborted by user\nCancelling the manual approval request\n",
+			exitCode:     ExitCancelled,
+			err:          "approval request a-1: Workflow approval request was aborted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Prepare
+			var mu sync.Mutex
+			polls := 0
+			statusPost := ""
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				switch r.Method + " " + r.URL.Path {
+				case "POST /v1/workflows/approval":
+					_, _ = w.Write([]byte(`{"id":"a-1","approvers":[{"userName":"testUserName","userId":"123","email":"user@mail.com"}]}`))
+				case "GET /v1/workflows/approval/a-1":
+					_, _ = w.Write([]byte(tt.statuses[min(polls, len(tt.statuses)-1)]))
+					polls++
+					if tt.cancel {
+						cancel()
+					}
+				case "POST /v1/workflows/approval/status":
+					statusPost = string(body)
+					_, _ = w.Write([]byte(`{}`))
+				default:
+					http.NotFound(w, r)
+				}
+			}))
+			defer server.Close()
+
+			dir := t.TempDir()
+			var testOutput strings.Builder
+
+			// Run
+			c := Config{
+				Context:      ctx,
+				URL:          server.URL,
+				OutputsDir:   dir,
+				StatusFile:   filepath.Join(dir, "status"),
+				PollInterval: time.Millisecond,
+				Timeout:      tt.timeout,
+				Output: &MockStdOut{
+					MockPrintf: func(format string, a ...any) {
+						testOutput.WriteString(fmt.Sprintf(format, a...))
+					},
+					MockPrintln: func(a ...any) {
+						testOutput.WriteString(fmt.Sprintln(a...))
+					},
+				},
+			}
+			t.Setenv("API_TOKEN", "test")
+			err := c.wait()
+
+			// Verify
+			if tt.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				require.Equal(t, tt.err, err.Error())
+			}
+			require.Equal(t, tt.exitCode, ExitCode(err))
+			require.Equal(t, tt.output, testOutput.String())
+
+			requireStatusFile(t, tt.statusInFile, filepath.Join(dir, "status"))
+
+			if tt.inputValsInOutput != "" {
+				out, ferr := os.ReadFile(filepath.Join(dir, "approvalInputValues"))
+				require.NoError(t, ferr)
+				require.Equal(t, tt.inputValsInOutput, string(out))
+			}
+
+			if tt.statusPost != "" {
+				require.JSONEq(t, tt.statusPost, statusPost)
+			} else {
+				require.Empty(t, statusPost)
+			}
+		})
+	}
+}
@@ -1,12 +1,16 @@
 package main
 
 import (
-	"github.com/cloudbees-io/manual-approval/cmd"
 	"log"
+	"os"
+
+	"github.com/cloudbees-io/manual-approval/cmd"
+	"github.com/cloudbees-io/manual-approval/internal/manual_approval"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		log.Fatal(err)
+		log.Println(err)
+		os.Exit(manual_approval.ExitCode(err))
 	}
 }